@@ -0,0 +1,50 @@
+package chain
+
+import "math/big"
+
+const (
+	// MinBlobBaseFee is the minimal blob base fee (MIN_BASE_FEE_PER_BLOB_GAS of EIP-4844).
+	MinBlobBaseFee = 1
+
+	cancunBlobBaseFeeUpdateFraction = 3_338_477 // EIP-4844
+	pragueBlobBaseFeeUpdateFraction = 5_007_716 // EIP-7691
+)
+
+// BlobBaseFeeUpdateFraction returns BLOB_BASE_FEE_UPDATE_FRACTION of the fork active at given block
+// or 0 if blob transactions are not active.
+func (p *Profile) BlobBaseFeeUpdateFraction(block uint64) uint64 {
+	switch fork := p.ForkAt(block); {
+	case fork >= Prague:
+		return pragueBlobBaseFeeUpdateFraction
+	case fork >= Cancun:
+		return cancunBlobBaseFeeUpdateFraction
+	default:
+		return 0
+	}
+}
+
+// BlobBaseFee returns blob base fee of given block with given excess blob gas
+// or nil if blob transactions are not active.
+func (p *Profile) BlobBaseFee(block, excessBlobGas uint64) *big.Int {
+	fraction := p.BlobBaseFeeUpdateFraction(block)
+	if fraction == 0 {
+		return nil
+	}
+	return fakeExponential(big.NewInt(MinBlobBaseFee), new(big.Int).SetUint64(excessBlobGas), new(big.Int).SetUint64(fraction))
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator) using Taylor expansion.
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	var (
+		output = new(big.Int)
+		accum  = new(big.Int).Mul(factor, denominator)
+	)
+	for i := 1; accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(int64(i)))
+	}
+	return output.Div(output, denominator)
+}
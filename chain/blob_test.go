@@ -0,0 +1,31 @@
+package chain
+
+import "testing"
+
+func TestProfile_BlobBaseFee(t *testing.T) {
+	tests := []struct {
+		block uint64
+		want  int64 // -1 if blob transactions are not active
+	}{
+		{19_426_586, -1},
+		{19_426_587, 19}, // Cancun
+		{22_431_084, 7},  // Prague
+	}
+
+	for _, test := range tests {
+		got := Mainnet.BlobBaseFee(test.block, 10_000_000)
+		if test.want < 0 {
+			if got != nil {
+				t.Errorf("block %v: unexpected blob base fee %v", test.block, got)
+			}
+			continue
+		}
+		if got == nil || got.Int64() != test.want {
+			t.Errorf("block %v: unexpected blob base fee\ngot: %v\nwant: %v", test.block, got, test.want)
+		}
+	}
+
+	if got := Mainnet.BlobBaseFee(19_426_587, 0); got.Int64() != MinBlobBaseFee {
+		t.Fatalf("unexpected minimal blob base fee %v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+)
+
+// GasPerBlob is the blob gas consumed by a single blob (GAS_PER_BLOB of EIP-4844).
+const GasPerBlob = 1 << 17
+
+// CheckConservation returns an error if ss violates accounting invariants which hold without
+// executing the transaction. Deviations usually mean accounts are missing from the recorded InputSubstate.
+//   - The sender nonce is incremented by one. Failed contract creations may leave it unchanged.
+//   - Accounts missing from InputSubstate have zero balance and accounts missing from OutputSubstate
+//     are self-destructed. The total balance decreases by the burned fees, and by at most
+//     the balance of self-destructed accounts on top of it.
+//   - The coinbase gains at least GasUsed times the effective tip, or nothing if the chain BurnsFees.
+//     Value transferred by a successful transaction to or from the coinbase and fees paid by
+//     a coinbase sender are accounted for. Payments to the coinbase from within the EVM add to its gain.
+//
+// All found problems are joined into the returned error.
+func (p *Profile) CheckConservation(ss *substate.Substate) error {
+	if ss.Env == nil || ss.Message == nil || ss.Result == nil {
+		return errors.New("substate has no env, message or result")
+	}
+
+	var (
+		err error
+		env = ss.Env
+		msg = ss.Message
+		res = ss.Result
+	)
+	fail := func(format string, args ...any) {
+		err = errors.Join(err, fmt.Errorf(format, args...))
+	}
+
+	// sender nonce
+	sender, post := ss.InputSubstate[msg.From], ss.OutputSubstate[msg.From]
+	switch {
+	case sender == nil:
+		fail("sender %v is missing from input", msg.From)
+	case post == nil:
+		fail("sender %v is missing from output", msg.From)
+	case post.Nonce == sender.Nonce+1:
+	case post.Nonce == sender.Nonce && msg.To == nil && res.Status == 0:
+		// failed contract creation, status 0 means failure
+	default:
+		fail("sender nonce is %v after transaction, want %v", post.Nonce, sender.Nonce+1)
+	}
+
+	// fees
+	gasUsed := new(big.Int).SetUint64(res.GasUsed)
+	tip := new(big.Int).Mul(gasUsed, effectiveTip(msg, env.BaseFee))
+	burned := new(big.Int)
+	if env.BaseFee != nil {
+		burned.Mul(gasUsed, env.BaseFee)
+	}
+	paid := new(big.Int).Add(burned, tip)
+	if p.BurnsFees {
+		burned.Add(burned, tip)
+		tip.SetUint64(0)
+	}
+	if len(msg.BlobHashes) > 0 && env.BlobBaseFee != nil {
+		blobFee := new(big.Int).SetUint64(uint64(len(msg.BlobHashes)) * GasPerBlob)
+		blobFee.Mul(blobFee, env.BlobBaseFee)
+		burned.Add(burned, blobFee)
+		paid.Add(paid, blobFee)
+	}
+
+	// coinbase
+	want := new(big.Int).Set(tip)
+	if res.Status == 1 && msg.Value != nil {
+		// value is transferred only by successful transactions
+		if msg.To != nil && *msg.To == env.Coinbase {
+			want.Add(want, msg.Value)
+		}
+		if msg.From == env.Coinbase {
+			want.Sub(want, msg.Value)
+		}
+	}
+	if msg.From == env.Coinbase {
+		want.Sub(want, paid)
+	}
+	gained := new(big.Int).Sub(balanceOf(ss.OutputSubstate[env.Coinbase]), balanceOf(ss.InputSubstate[env.Coinbase]))
+	if gained.Cmp(want) < 0 {
+		fail("coinbase %v gained %v, want at least %v", env.Coinbase, gained, want)
+	}
+
+	// total balance
+	var (
+		change    = new(big.Int)
+		destroyed = new(big.Int)
+	)
+	for addr, acc := range ss.InputSubstate {
+		change.Sub(change, balanceOf(acc))
+		if ss.OutputSubstate[addr] == nil {
+			destroyed.Add(destroyed, balanceOf(acc))
+		}
+	}
+	for _, acc := range ss.OutputSubstate {
+		change.Add(change, balanceOf(acc))
+	}
+	// change must be within [-burned-destroyed, -burned]
+	lost := new(big.Int).Neg(change)
+	if lost.Cmp(burned) < 0 || lost.Cmp(new(big.Int).Add(burned, destroyed)) > 0 {
+		fail("total balance changed by %v, want -%v (up to %v self-destructed)", change, burned, destroyed)
+	}
+
+	if err != nil {
+		return fmt.Errorf("balance or nonce not conserved block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+	}
+	return nil
+}
+
+// effectiveTip returns the gas price paid above baseFee, or the whole gas price if baseFee is nil.
+func effectiveTip(msg *substate.Message, baseFee *big.Int) *big.Int {
+	gasPrice := msg.GasPrice
+	if gasPrice == nil {
+		gasPrice = new(big.Int)
+	}
+	if baseFee == nil {
+		return gasPrice
+	}
+	if msg.GasFeeCap == nil || msg.GasTipCap == nil {
+		return new(big.Int).Sub(gasPrice, baseFee)
+	}
+	tip := new(big.Int).Sub(msg.GasFeeCap, baseFee)
+	if tip.Cmp(msg.GasTipCap) > 0 {
+		tip.Set(msg.GasTipCap)
+	}
+	return tip
+}
+
+func balanceOf(acc *substate.Account) *big.Int {
+	if acc == nil || acc.Balance == nil {
+		return new(big.Int)
+	}
+	return acc.Balance
+}
@@ -0,0 +1,114 @@
+package chain
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+var (
+	testSender    = types.Address{0x01}
+	testRecipient = types.Address{0x02}
+	testCoinbase  = types.Address{0x03}
+)
+
+// newTransferSubstate returns a London transfer of 100 wei paying base fee 10 and tip 2 per gas.
+func newTransferSubstate(burnsFees bool) *substate.Substate {
+	const gasUsed = 21_000
+	fee, tip := int64(gasUsed*12), int64(gasUsed*2)
+
+	env := substate.NewEnv(testCoinbase, big.NewInt(0), 30_000_000, 1, 1, big.NewInt(10), nil, nil)
+	msg := substate.NewMessage(5, true, big.NewInt(12), gasUsed, testSender, &testRecipient, big.NewInt(100), nil, nil, nil, big.NewInt(15), big.NewInt(2), nil, nil)
+
+	pre := substate.NewWorldState().
+		Add(testSender, 5, big.NewInt(1_000_000), nil).
+		Add(testCoinbase, 0, big.NewInt(0), nil)
+	post := substate.NewWorldState().
+		Add(testSender, 6, big.NewInt(1_000_000-100-fee), nil).
+		Add(testRecipient, 0, big.NewInt(100), nil).
+		Add(testCoinbase, 0, big.NewInt(tip), nil)
+	if burnsFees {
+		post[testCoinbase].Balance = big.NewInt(0)
+	}
+
+	return substate.NewSubstate(pre, post, env, msg, substate.NewResult(1, types.Bloom{}, nil, types.Address{}, gasUsed), 1, 0)
+}
+
+func TestProfile_CheckConservation(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile *Profile
+		modify  func(ss *substate.Substate)
+		wantErr string // empty if conserved
+	}{
+		{"transfer", Mainnet, func(ss *substate.Substate) {}, ""},
+		{"fees burned", Opera, func(ss *substate.Substate) {}, ""},
+		{"recipient missing from input", Mainnet, func(ss *substate.Substate) {
+			ss.OutputSubstate[testRecipient].Balance = big.NewInt(150)
+		}, "total balance changed by -209950, want -210000"},
+		{"coinbase missing", Mainnet, func(ss *substate.Substate) {
+			delete(ss.InputSubstate, testCoinbase)
+			delete(ss.OutputSubstate, testCoinbase)
+		}, "coinbase 0x0300000000000000000000000000000000000000 gained 0, want at least 42000"},
+		{"coinbase paid within transaction", Mainnet, func(ss *substate.Substate) {
+			// recipient pays 1 wei to the coinbase
+			ss.OutputSubstate[testRecipient].Balance = big.NewInt(99)
+			ss.OutputSubstate[testCoinbase].Balance = big.NewInt(42_001)
+		}, ""},
+		{"coinbase is recipient", Mainnet, func(ss *substate.Substate) {
+			ss.Message.To = &testCoinbase
+			delete(ss.OutputSubstate, testRecipient)
+			ss.OutputSubstate[testCoinbase].Balance = big.NewInt(42_100)
+		}, ""},
+		{"coinbase is recipient of failed transaction", Mainnet, func(ss *substate.Substate) {
+			ss.Message.To = &testCoinbase
+			ss.Result.Status = 0
+			delete(ss.OutputSubstate, testRecipient)
+			ss.OutputSubstate[testSender].Balance = big.NewInt(1_000_000 - 252_000)
+			ss.OutputSubstate[testCoinbase].Balance = big.NewInt(42_100)
+		}, "total balance changed by -209900, want -210000"},
+		{"coinbase is sender", Mainnet, func(ss *substate.Substate) {
+			ss.Message.From = testCoinbase
+			ss.InputSubstate[testCoinbase] = ss.InputSubstate[testSender]
+			ss.OutputSubstate[testCoinbase] = ss.OutputSubstate[testSender]
+			ss.OutputSubstate[testCoinbase].Balance = big.NewInt(1_000_000 - 100 - 252_000 + 42_000)
+			delete(ss.InputSubstate, testSender)
+			delete(ss.OutputSubstate, testSender)
+		}, ""},
+		{"nonce not incremented", Mainnet, func(ss *substate.Substate) {
+			ss.OutputSubstate[testSender].Nonce = 5
+		}, "sender nonce is 5 after transaction, want 6"},
+		{"failed creation", Mainnet, func(ss *substate.Substate) {
+			ss.Message.To = nil
+			ss.Result.Status = 0
+			ss.OutputSubstate[testSender].Nonce = 5
+		}, ""},
+		{"self-destruct", Mainnet, func(ss *substate.Substate) {
+			ss.InputSubstate.Add(types.Address{0x04}, 1, big.NewInt(7), []byte{0xff})
+		}, ""},
+		{"sender missing", Mainnet, func(ss *substate.Substate) {
+			delete(ss.InputSubstate, testSender)
+		}, "sender 0x0100000000000000000000000000000000000000 is missing from input"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ss := newTransferSubstate(test.profile.BurnsFees)
+			test.modify(ss)
+
+			err := test.profile.CheckConservation(ss)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error; %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("unexpected error\ngot: %v\nwant: %v", err, test.wantErr)
+			}
+		})
+	}
+}
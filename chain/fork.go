@@ -0,0 +1,62 @@
+// Package chain describes fork schedules of chains recorded into substate DBs.
+package chain
+
+import "fmt"
+
+// Fork is a protocol upgrade changing the fields of recorded substates or their execution.
+// Forks are ordered by their activation on every supported chain.
+type Fork uint8
+
+const (
+	Frontier Fork = iota
+	Homestead
+	TangerineWhistle // EIP-150
+	SpuriousDragon   // EIP-155, EIP-158
+	Byzantium
+	Constantinople
+	Petersburg
+	Istanbul
+	Berlin   // EIP-2930 access lists
+	London   // EIP-1559 base fee and dynamic fee transactions
+	Paris    // the merge, PREVRANDAO replaces difficulty
+	Shanghai // EIP-4895 withdrawals
+	Cancun   // EIP-4844 blob transactions, EIP-4788 parent beacon block root
+	Prague   // EIP-7691 blob throughput increase
+
+	// LatestFork is the latest fork known to this package.
+	LatestFork = Prague
+)
+
+var forkNames = [...]string{
+	Frontier:         "Frontier",
+	Homestead:        "Homestead",
+	TangerineWhistle: "TangerineWhistle",
+	SpuriousDragon:   "SpuriousDragon",
+	Byzantium:        "Byzantium",
+	Constantinople:   "Constantinople",
+	Petersburg:       "Petersburg",
+	Istanbul:         "Istanbul",
+	Berlin:           "Berlin",
+	London:           "London",
+	Paris:            "Paris",
+	Shanghai:         "Shanghai",
+	Cancun:           "Cancun",
+	Prague:           "Prague",
+}
+
+func (f Fork) String() string {
+	if int(f) < len(forkNames) {
+		return forkNames[f]
+	}
+	return fmt.Sprintf("Fork(%d)", uint8(f))
+}
+
+// ParseFork returns the fork with given name.
+func ParseFork(name string) (Fork, error) {
+	for f, n := range forkNames {
+		if n == name {
+			return Fork(f), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown fork %q", name)
+}
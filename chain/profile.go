@@ -0,0 +1,110 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Activation is the first block of a fork on a chain.
+type Activation struct {
+	Fork  Fork
+	Block uint64 // first block of the fork
+
+	// Time is the activation timestamp of forks scheduled by time, nil for forks scheduled by block.
+	// Block is the first block produced at or after Time and is what the Profile relies on.
+	Time *uint64
+}
+
+// Profile is a fork schedule and fee model of a chain.
+type Profile struct {
+	Name    string
+	ChainID uint64
+
+	// BurnsFees is set for chains which do not pay transaction fees to the coinbase,
+	// the whole fee leaves the EVM state. Otherwise, only the base fee is burned.
+	BurnsFees bool
+
+	// BeaconChain is set for chains run by a beacon chain. Only these chains record withdrawals,
+	// parent beacon block roots and blob gas of blocks, other chains may leave them unset.
+	BeaconChain bool
+
+	activations []Activation // ordered by fork
+}
+
+// NewProfile returns a profile of a custom chain. Activations must be listed in fork order
+// with non-decreasing blocks and the first of them must activate at block 0.
+// Forks preceding the first listed fork are active from the genesis,
+// forks skipped between two listed forks activate together with the later one.
+func NewProfile(name string, chainID uint64, activations ...Activation) (*Profile, error) {
+	if len(activations) == 0 {
+		return nil, errors.New("no fork activations")
+	}
+	if activations[0].Block != 0 {
+		return nil, fmt.Errorf("first fork %v must activate at block 0, got %v", activations[0].Fork, activations[0].Block)
+	}
+	for i := 1; i < len(activations); i++ {
+		prev, cur := activations[i-1], activations[i]
+		if cur.Fork <= prev.Fork {
+			return nil, fmt.Errorf("fork %v listed after %v", cur.Fork, prev.Fork)
+		}
+		if cur.Fork > LatestFork {
+			return nil, fmt.Errorf("unknown fork %v", cur.Fork)
+		}
+		if cur.Block < prev.Block {
+			return nil, fmt.Errorf("fork %v activates at block %v before %v at block %v", cur.Fork, cur.Block, prev.Fork, prev.Block)
+		}
+	}
+
+	p := &Profile{
+		Name:        name,
+		ChainID:     chainID,
+		activations: make([]Activation, len(activations)),
+	}
+	copy(p.activations, activations)
+	return p, nil
+}
+
+func mustNewProfile(name string, chainID uint64, activations ...Activation) *Profile {
+	p, err := NewProfile(name, chainID, activations...)
+	if err != nil {
+		panic(fmt.Sprintf("invalid profile %v; %v", name, err))
+	}
+	return p
+}
+
+// ForkAt returns the latest fork active at given block.
+func (p *Profile) ForkAt(block uint64) Fork {
+	// index of the first activation after block, the first activation is always at block 0
+	i := sort.Search(len(p.activations), func(i int) bool {
+		return p.activations[i].Block > block
+	})
+	return p.activations[i-1].Fork
+}
+
+// IsActive returns true if fork is active at given block.
+func (p *Profile) IsActive(fork Fork, block uint64) bool {
+	return p.ForkAt(block) >= fork
+}
+
+// Activation returns the activation of given fork. False is returned
+// if the fork is not listed in the profile, e.g. it is not scheduled yet.
+func (p *Profile) Activation(fork Fork) (Activation, bool) {
+	for _, a := range p.activations {
+		if a.Fork == fork {
+			return a, true
+		}
+	}
+	return Activation{}, false
+}
+
+// Activations returns all fork activations of the profile ordered by fork.
+func (p *Profile) Activations() []Activation {
+	activations := make([]Activation, len(p.activations))
+	copy(activations, p.activations)
+	return activations
+}
+
+func (p *Profile) String() string {
+	return fmt.Sprintf("%v (chain ID %v)", p.Name, p.ChainID)
+}
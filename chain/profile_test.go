@@ -0,0 +1,90 @@
+package chain
+
+import "testing"
+
+func TestProfile_ForkAt(t *testing.T) {
+	tests := []struct {
+		profile *Profile
+		block   uint64
+		want    Fork
+	}{
+		{Mainnet, 0, Frontier},
+		{Mainnet, 7_280_000, Petersburg},
+		{Mainnet, 12_964_999, Berlin},
+		{Mainnet, 12_965_000, London},
+		{Mainnet, 19_426_587, Cancun},
+		{Sepolia, 0, London},
+		{Opera, 37_455_222, Istanbul},
+		{Opera, 37_455_223, Berlin},
+		{Opera, 37_534_833, London},
+		{Opera, 100_000_000, London},
+		{Sonic, 0, Cancun},
+	}
+
+	for _, test := range tests {
+		if got := test.profile.ForkAt(test.block); got != test.want {
+			t.Errorf("%v at block %v: unexpected fork\ngot: %v\nwant: %v", test.profile, test.block, got, test.want)
+		}
+	}
+}
+
+func TestProfile_IsActive(t *testing.T) {
+	// forks preceding the first listed fork are active from the genesis
+	if !Opera.IsActive(Byzantium, 0) {
+		t.Fatal("Byzantium must be active at Opera genesis")
+	}
+	if Opera.IsActive(Paris, 100_000_000) {
+		t.Fatal("Paris must not be active on Opera")
+	}
+	// forks skipped between listed forks activate with the later one
+	p, err := NewProfile("custom", 1337, Activation{Fork: Istanbul}, Activation{Fork: Shanghai, Block: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.IsActive(London, 9) || !p.IsActive(London, 10) {
+		t.Fatal("London must activate with Shanghai")
+	}
+}
+
+func TestNewProfile_RejectsInvalidSchedule(t *testing.T) {
+	tests := map[string][]Activation{
+		"empty":       nil,
+		"no genesis":  {{Fork: London, Block: 1}},
+		"fork order":  {{Fork: London}, {Fork: Berlin, Block: 1}},
+		"block order": {{Fork: Berlin}, {Fork: London, Block: 2}, {Fork: Paris, Block: 1}},
+		"unknown":     {{Fork: Berlin}, {Fork: LatestFork + 1, Block: 1}},
+	}
+
+	for name, activations := range tests {
+		if _, err := NewProfile(name, 1337, activations...); err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+	}
+}
+
+func TestProfileByChainID(t *testing.T) {
+	for _, want := range Profiles() {
+		got, err := ProfileByChainID(want.ChainID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("unexpected profile\ngot: %v\nwant: %v", got, want)
+		}
+	}
+	if _, err := ProfileByChainID(1337); err == nil {
+		t.Fatal("expected an error for unknown chain ID")
+	}
+}
+
+func TestParseFork(t *testing.T) {
+	for f := Frontier; f <= LatestFork; f++ {
+		got, err := ParseFork(f.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != f {
+			t.Fatalf("unexpected fork\ngot: %v\nwant: %v", got, f)
+		}
+	}
+}
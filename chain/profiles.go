@@ -0,0 +1,85 @@
+package chain
+
+import "fmt"
+
+func timestamp(t uint64) *uint64 {
+	return &t
+}
+
+func burningFees(p *Profile) *Profile {
+	p.BurnsFees = true
+	return p
+}
+
+func withBeaconChain(p *Profile) *Profile {
+	p.BeaconChain = true
+	return p
+}
+
+var (
+	// Mainnet is the Ethereum mainnet.
+	Mainnet = withBeaconChain(mustNewProfile("mainnet", 1,
+		Activation{Fork: Frontier, Block: 0},
+		Activation{Fork: Homestead, Block: 1_150_000},
+		Activation{Fork: TangerineWhistle, Block: 2_463_000},
+		Activation{Fork: SpuriousDragon, Block: 2_675_000},
+		Activation{Fork: Byzantium, Block: 4_370_000},
+		Activation{Fork: Constantinople, Block: 7_280_000},
+		Activation{Fork: Petersburg, Block: 7_280_000},
+		Activation{Fork: Istanbul, Block: 9_069_000},
+		Activation{Fork: Berlin, Block: 12_244_000},
+		Activation{Fork: London, Block: 12_965_000},
+		Activation{Fork: Paris, Block: 15_537_394},
+		Activation{Fork: Shanghai, Block: 17_034_870, Time: timestamp(1_681_338_455)},
+		Activation{Fork: Cancun, Block: 19_426_587, Time: timestamp(1_710_338_135)},
+		Activation{Fork: Prague, Block: 22_431_084, Time: timestamp(1_746_612_311)},
+	))
+
+	// Sepolia is the Ethereum Sepolia testnet.
+	Sepolia = withBeaconChain(mustNewProfile("sepolia", 11_155_111,
+		Activation{Fork: London, Block: 0},
+		Activation{Fork: Paris, Block: 1_450_409},
+		Activation{Fork: Shanghai, Block: 2_990_908, Time: timestamp(1_677_557_088)},
+		Activation{Fork: Cancun, Block: 5_187_023, Time: timestamp(1_706_655_072)},
+		Activation{Fork: Prague, Block: 7_836_331, Time: timestamp(1_741_159_776)},
+	))
+
+	// Opera is the Fantom Opera mainnet.
+	// Fees are collected by the SFC contract instead of the coinbase.
+	Opera = burningFees(mustNewProfile("opera", 250,
+		Activation{Fork: Istanbul, Block: 0},
+		Activation{Fork: Berlin, Block: 37_455_223},
+		Activation{Fork: London, Block: 37_534_833},
+	))
+
+	// Sonic is the Sonic mainnet.
+	// Fees are collected by the SFC contract instead of the coinbase.
+	Sonic = burningFees(mustNewProfile("sonic", 146,
+		Activation{Fork: Cancun, Block: 0},
+	))
+)
+
+// Profiles returns profiles of all known chains.
+func Profiles() []*Profile {
+	return []*Profile{Mainnet, Sepolia, Opera, Sonic}
+}
+
+// ProfileByChainID returns the profile of a known chain with given chain ID.
+func ProfileByChainID(chainID uint64) (*Profile, error) {
+	for _, p := range Profiles() {
+		if p.ChainID == chainID {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown chain ID %v", chainID)
+}
+
+// ProfileByName returns the profile of a known chain with given name.
+func ProfileByName(name string) (*Profile, error) {
+	for _, p := range Profiles() {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown chain %q", name)
+}
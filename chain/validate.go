@@ -0,0 +1,103 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+)
+
+// BlobHashVersion is the version byte of blob versioned hashes (VERSIONED_HASH_VERSION_KZG of EIP-4844).
+const BlobHashVersion = 0x01
+
+// txTypeForks holds forks introducing transaction types.
+var txTypeForks = [...]Fork{
+	substate.LegacyTxType:     Frontier,
+	substate.AccessListTxType: Berlin,
+	substate.DynamicFeeTxType: London,
+	substate.BlobTxType:       Cancun,
+}
+
+// Validate returns an error if ss is not valid for the fork active at its block.
+// Fields and transaction types introduced by a fork must not be used before it,
+// Env fields introduced by a fork must be set since it. Withdrawals may be empty but not nil since Shanghai.
+// Fields recorded from the beacon chain are only required if the profile has a BeaconChain.
+// Gas limit, fee caps, blob hashes and the chain ID are checked as well.
+// All found problems are joined into the returned error.
+func (p *Profile) Validate(ss *substate.Substate) error {
+	if ss.Env == nil || ss.Message == nil {
+		return errors.New("substate has no env or message")
+	}
+
+	var (
+		err  error
+		fork = p.ForkAt(ss.Block)
+		env  = ss.Env
+		msg  = ss.Message
+	)
+	fail := func(format string, args ...any) {
+		err = errors.Join(err, fmt.Errorf(format, args...))
+	}
+
+	// fields of forks
+	checkSince := func(name string, since Fork, set bool) {
+		if fork < since && set {
+			fail("%v is set before %v", name, since)
+		}
+		if fork >= since && !set {
+			fail("%v is not set since %v", name, since)
+		}
+	}
+	checkBeaconSince := func(name string, since Fork, set bool) {
+		if p.BeaconChain {
+			checkSince(name, since, set)
+		} else if fork < since && set {
+			fail("%v is set before %v", name, since)
+		}
+	}
+	checkSince("base fee", London, env.BaseFee != nil)
+	checkSince("prev randao", Paris, env.PrevRandao != nil)
+	checkBeaconSince("withdrawals", Shanghai, env.Withdrawals != nil)
+	checkSince("blob base fee", Cancun, env.BlobBaseFee != nil)
+	checkBeaconSince("parent beacon block root", Cancun, env.ParentBeaconBlockRoot != nil)
+	checkBeaconSince("excess blob gas", Cancun, env.ExcessBlobGas != nil)
+	checkBeaconSince("blob gas used", Cancun, env.BlobGasUsed != nil)
+
+	txType := msg.InferType()
+	if int(txType) >= len(txTypeForks) {
+		fail("unknown transaction type %v", txType)
+	} else if fork < txTypeForks[txType] {
+		fail("transaction of type %v before %v", txType, txTypeForks[txType])
+	}
+
+	// blob transactions
+	if txType == substate.BlobTxType && len(msg.BlobHashes) == 0 {
+		fail("blob transaction without blob hashes")
+	}
+	if len(msg.BlobHashes) == 0 && msg.BlobGasFeeCap != nil && msg.BlobGasFeeCap.Sign() > 0 {
+		fail("blob gas fee cap %v is set without blob hashes", msg.BlobGasFeeCap)
+	}
+	for i, h := range msg.BlobHashes {
+		if h[0] != BlobHashVersion {
+			fail("blob hash %v has version %#x, want %#x", i, h[0], BlobHashVersion)
+		}
+	}
+
+	// fees and gas
+	if msg.GasFeeCap != nil && msg.GasTipCap != nil && msg.GasFeeCap.Cmp(msg.GasTipCap) < 0 {
+		fail("gas fee cap %v is lower than gas tip cap %v", msg.GasFeeCap, msg.GasTipCap)
+	}
+	if msg.Gas > env.GasLimit {
+		fail("gas %v exceeds block gas limit %v", msg.Gas, env.GasLimit)
+	}
+
+	if msg.ChainID != nil && msg.ChainID.Cmp(new(big.Int).SetUint64(p.ChainID)) != 0 {
+		fail("chain ID %v does not match %v", msg.ChainID, p.ChainID)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid substate block: %v, tx: %v for %v at %v; %w", ss.Block, ss.Transaction, p.Name, fork, err)
+	}
+	return nil
+}
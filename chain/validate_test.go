@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func newTestSubstate(block uint64) *substate.Substate {
+	env := substate.NewEnv(types.Address{1}, big.NewInt(0), 30_000_000, block, 1, nil, nil, nil)
+	msg := substate.NewMessage(0, true, big.NewInt(1), 21_000, types.Address{2}, &types.Address{3}, big.NewInt(0), nil, nil, nil, big.NewInt(1), big.NewInt(1), nil, nil)
+	return substate.NewSubstate(substate.NewWorldState(), substate.NewWorldState(), env, msg, substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000), block, 0)
+}
+
+// forkFields returns a modifier setting all env fields required at fork.
+func forkFields(fork Fork) func(ss *substate.Substate) {
+	return func(ss *substate.Substate) {
+		env := ss.Env
+		if fork >= London {
+			env.BaseFee = big.NewInt(1)
+		}
+		if fork >= Paris {
+			env.PrevRandao = &types.Hash{1}
+		}
+		if fork >= Shanghai {
+			env.Withdrawals = []*types.Withdrawal{}
+		}
+		if fork >= Cancun {
+			excessBlobGas, blobGasUsed := uint64(0), uint64(0)
+			env.BlobBaseFee = big.NewInt(1)
+			env.ParentBeaconBlockRoot = &types.Hash{2}
+			env.ExcessBlobGas, env.BlobGasUsed = &excessBlobGas, &blobGasUsed
+		}
+	}
+}
+
+func TestProfile_Validate(t *testing.T) {
+	blobType := substate.BlobTxType
+	tests := []struct {
+		name    string
+		profile *Profile
+		block   uint64
+		modify  func(ss *substate.Substate)
+		wantErr string // empty if valid
+	}{
+		{"legacy", Mainnet, 1, func(ss *substate.Substate) {}, ""},
+		{"base fee before London", Mainnet, 1, func(ss *substate.Substate) {
+			ss.Env.BaseFee = big.NewInt(1)
+		}, "base fee is set before London"},
+		{"no base fee since London", Opera, 37_534_833, func(ss *substate.Substate) {}, "base fee is not set since London"},
+		{"dynamic fee", Opera, 37_534_833, func(ss *substate.Substate) {
+			ss.Env.BaseFee = big.NewInt(1)
+			ss.Message.GasFeeCap = big.NewInt(2)
+		}, ""},
+		{"dynamic fee before London", Opera, 37_534_832, func(ss *substate.Substate) {
+			ss.Message.GasFeeCap = big.NewInt(2)
+		}, "transaction of type 2 before London"},
+		{"access list before Berlin", Opera, 1, func(ss *substate.Substate) {
+			ss.Message.AccessList = types.AccessList{{Address: types.Address{3}}}
+		}, "transaction of type 1 before Berlin"},
+		{"blob", Sonic, 1, func(ss *substate.Substate) {
+			forkFields(Cancun)(ss)
+			ss.Message.BlobHashes = []types.Hash{{BlobHashVersion}}
+		}, ""},
+		{"blob without hashes", Sonic, 1, func(ss *substate.Substate) {
+			forkFields(Cancun)(ss)
+			ss.Message.Type = &blobType
+		}, "blob transaction without blob hashes"},
+		{"blob fee cap without hashes", Sonic, 1, func(ss *substate.Substate) {
+			forkFields(Cancun)(ss)
+			ss.Message.BlobGasFeeCap = big.NewInt(1)
+		}, "blob gas fee cap 1 is set without blob hashes"},
+		{"blob hash version", Sonic, 1, func(ss *substate.Substate) {
+			forkFields(Cancun)(ss)
+			ss.Message.BlobHashes = []types.Hash{{BlobHashVersion}, {0x02}}
+		}, "blob hash 1 has version 0x2"},
+		{"blob before Cancun", Mainnet, 19_426_586, func(ss *substate.Substate) {
+			forkFields(Shanghai)(ss)
+			ss.Message.BlobHashes = []types.Hash{{BlobHashVersion}}
+		}, "transaction of type 3 before Cancun"},
+		{"Paris", Mainnet, 15_537_394, forkFields(Paris), ""},
+		{"prev randao before Paris", Mainnet, 15_537_393, forkFields(Paris), "prev randao is set before Paris"},
+		{"no prev randao since Paris", Mainnet, 15_537_394, forkFields(London), "prev randao is not set since Paris"},
+		{"Shanghai", Mainnet, 17_034_870, forkFields(Shanghai), ""},
+		{"withdrawals before Shanghai", Mainnet, 17_034_869, forkFields(Shanghai), "withdrawals is set before Shanghai"},
+		{"no withdrawals since Shanghai", Mainnet, 17_034_870, forkFields(Paris), "withdrawals is not set since Shanghai"},
+		{"Cancun", Mainnet, 19_426_587, forkFields(Cancun), ""},
+		{"blob base fee before Cancun", Mainnet, 19_426_586, forkFields(Cancun), "blob base fee is set before Cancun"},
+		{"parent beacon block root before Cancun", Mainnet, 19_426_586, forkFields(Cancun), "parent beacon block root is set before Cancun"},
+		{"excess blob gas before Cancun", Mainnet, 19_426_586, forkFields(Cancun), "excess blob gas is set before Cancun"},
+		{"blob gas used before Cancun", Mainnet, 19_426_586, forkFields(Cancun), "blob gas used is set before Cancun"},
+		{"no blob base fee since Cancun", Mainnet, 19_426_587, forkFields(Shanghai), "blob base fee is not set since Cancun"},
+		{"no parent beacon block root since Cancun", Mainnet, 19_426_587, forkFields(Shanghai), "parent beacon block root is not set since Cancun"},
+		{"no excess blob gas since Cancun", Mainnet, 19_426_587, forkFields(Shanghai), "excess blob gas is not set since Cancun"},
+		{"no blob gas used since Cancun", Mainnet, 19_426_587, forkFields(Shanghai), "blob gas used is not set since Cancun"},
+		{"Cancun without beacon chain", Sonic, 1, func(ss *substate.Substate) {
+			ss.Env.BaseFee = big.NewInt(1)
+			ss.Env.PrevRandao = &types.Hash{1}
+			ss.Env.BlobBaseFee = big.NewInt(1)
+		}, ""},
+		{"no blob base fee without beacon chain", Sonic, 1, forkFields(Shanghai), "blob base fee is not set since Cancun"},
+		{"withdrawals before Shanghai without beacon chain", Opera, 37_534_833, forkFields(Shanghai), "withdrawals is set before Shanghai"},
+		{"tip cap above fee cap", Mainnet, 1, func(ss *substate.Substate) {
+			ss.Message.GasTipCap = big.NewInt(2)
+		}, "gas fee cap 1 is lower than gas tip cap 2"},
+		{"gas above gas limit", Mainnet, 1, func(ss *substate.Substate) {
+			ss.Message.Gas = ss.Env.GasLimit + 1
+		}, "exceeds block gas limit"},
+		{"chain ID", Mainnet, 1, func(ss *substate.Substate) {
+			ss.Message.ChainID = big.NewInt(250)
+		}, "chain ID 250 does not match 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ss := newTestSubstate(test.block)
+			test.modify(ss)
+
+			err := test.profile.Validate(ss)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error; %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("unexpected error\ngot: %v\nwant: %v", err, test.wantErr)
+			}
+		})
+	}
+}
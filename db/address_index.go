@@ -0,0 +1,274 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	ldbiterator "github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/Fantom-foundation/Substate/rlp"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const AddressIndexDBPrefix = "1a" // AddressIndexDBPrefix + address (160-bit) + block (64-bit) + tx (64-bit) -> nil
+
+// BuildAddressIndex indexes all transactions between first and last block (including first and last)
+// and extends the recorded coverage of the AddressIndex by the block range.
+func (db *substateDB) BuildAddressIndex(first, last uint64, workers int) error {
+	pool := &SubstateTaskPool{
+		Name: "build-address-index",
+		TaskFunc: func(_ uint64, _ int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			return putAddresses(db, ss)
+		},
+
+		First: first,
+		Last:  last,
+
+		Workers: workers,
+		DB:      db,
+	}
+
+	if err := pool.Execute(); err != nil {
+		return err
+	}
+	return db.coverIndex(AddressIndexCoverageKey, first, last)
+}
+
+// NewAddressSubstateIterator returns iterator which iterates over Substates touching addr between first and last block.
+// The AddressIndex is used within its recorded coverage, substates outside of it are scanned.
+func (db *substateDB) NewAddressSubstateIterator(addr types.Address, first, last uint64) Iterator[*substate.Substate] {
+	segments, err := db.indexSegments(AddressIndexCoverageKey, first, last)
+	iter := newAddressSubstateIterator(db, addr, segments)
+	if err != nil {
+		iter.err = err
+		return iter
+	}
+
+	iter.start(0)
+
+	return iter
+}
+
+// addressKeyRange returns range of keys iterated to find substates touching addr within segment s.
+func addressKeyRange(addr types.Address, s indexSegment) *util.Range {
+	if s.useIndex {
+		return &util.Range{
+			Start: AddressIndexDBBlockPrefix(addr, s.first),
+			Limit: addressIndexDBBlockLimit(addr, s.last),
+		}
+	}
+	return &util.Range{
+		Start: SubstateDBBlockPrefix(s.first),
+		Limit: substateDBBlockLimit(s.last),
+	}
+}
+
+// TouchedAddresses returns addresses of all accounts touched by given substate.
+// These are accounts within InputSubstate and OutputSubstate, sender and recipient of the message
+// and the created contract.
+func TouchedAddresses(ss *substate.Substate) []types.Address {
+	touched := make(map[types.Address]struct{})
+	for addr := range ss.InputSubstate {
+		touched[addr] = struct{}{}
+	}
+	for addr := range ss.OutputSubstate {
+		touched[addr] = struct{}{}
+	}
+	if msg := ss.Message; msg != nil {
+		touched[msg.From] = struct{}{}
+		if msg.To != nil {
+			touched[*msg.To] = struct{}{}
+		} else if ss.Result != nil {
+			touched[ss.Result.ContractAddress] = struct{}{}
+		}
+	}
+
+	addresses := make([]types.Address, 0, len(touched))
+	for addr := range touched {
+		addresses = append(addresses, addr)
+	}
+	return addresses
+}
+
+func putAddresses(w KeyValueWriter, ss *substate.Substate) error {
+	for _, addr := range TouchedAddresses(ss) {
+		if err := w.Put(AddressIndexDBKey(addr, ss.Block, ss.Transaction), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteAddresses(w KeyValueWriter, ss *substate.Substate) error {
+	for _, addr := range TouchedAddresses(ss) {
+		if err := w.Delete(AddressIndexDBKey(addr, ss.Block, ss.Transaction)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// touches returns true if the substate encoded in r touches addr.
+func touches(r *rlp.RLP, addr types.Address) bool {
+	for _, a := range r.InputSubstate.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	for _, a := range r.OutputSubstate.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	if r.Message.From == addr {
+		return true
+	}
+	if r.Message.To != nil {
+		return *r.Message.To == addr
+	}
+	return r.Result.ContractAddress == addr
+}
+
+// AddressIndexDBKey returns AddressIndexDBPrefix with appended
+// address, block and tx number creating key used in baseDB for the AddressIndex.
+func AddressIndexDBKey(addr types.Address, block uint64, tx int) []byte {
+	key := AddressIndexDBBlockPrefix(addr, block)
+	txBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(txBytes, uint64(tx))
+	return append(key, txBytes...)
+}
+
+// AddressIndexDBBlockPrefix returns AddressIndexDBPrefix with appended
+// address and block number creating prefix used in baseDB for the AddressIndex.
+func AddressIndexDBBlockPrefix(addr types.Address, block uint64) []byte {
+	prefix := append([]byte(AddressIndexDBPrefix), addr[:]...)
+	return append(prefix, BlockToBytes(block)...)
+}
+
+// addressIndexDBBlockLimit returns the first address index key after all entries of given address and block.
+func addressIndexDBBlockLimit(addr types.Address, block uint64) []byte {
+	if block == ^uint64(0) {
+		return util.BytesPrefix(append([]byte(AddressIndexDBPrefix), addr[:]...)).Limit
+	}
+	return AddressIndexDBBlockPrefix(addr, block+1)
+}
+
+// DecodeAddressIndexDBKey decodes key created by AddressIndexDBKey back to address, block and tx number.
+func DecodeAddressIndexDBKey(key []byte) (addr types.Address, block uint64, tx int, err error) {
+	prefix := AddressIndexDBPrefix
+	if len(key) != len(prefix)+types.AddressLength+8+8 {
+		err = fmt.Errorf("invalid length of address index key: %v", len(key))
+		return
+	}
+	if p := string(key[:len(prefix)]); p != prefix {
+		err = fmt.Errorf("invalid prefix of address index key: %#x", p)
+		return
+	}
+	addr = types.BytesToAddress(key[len(prefix) : len(prefix)+types.AddressLength])
+	blockTx := key[len(prefix)+types.AddressLength:]
+	block = binary.BigEndian.Uint64(blockTx[0:8])
+	tx = int(binary.BigEndian.Uint64(blockTx[8:16]))
+	return
+}
+
+func newAddressSubstateIterator(db *substateDB, addr types.Address, segments []indexSegment) *addressSubstateIterator {
+	return &addressSubstateIterator{
+		// segments are iterated one by one by start
+		iterator: newIterator[*substate.Substate](ldbiterator.NewEmptyIterator(nil)),
+		db:       db,
+		addr:     addr,
+		segments: segments,
+	}
+}
+
+// addressSubstateIterator iterates over substates touching an address.
+// It follows the AddressIndex within segments covered by it and scans all substates in other segments.
+type addressSubstateIterator struct {
+	iterator[*substate.Substate]
+	db       *substateDB
+	addr     types.Address
+	segments []indexSegment
+	useIndex bool // set for the currently iterated segment
+}
+
+// decode returns substate for given raw entry or nil if the substate does not touch the address.
+func (i *addressSubstateIterator) decode(data rawEntry) (*substate.Substate, error) {
+	if i.useIndex {
+		_, block, tx, err := DecodeAddressIndexDBKey(data.key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address index key: %v; %w", data.key, err)
+		}
+		return i.db.GetSubstate(block, tx)
+	}
+
+	block, tx, err := DecodeSubstateDBKey(data.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid substate key: %v; %w", data.key, err)
+	}
+
+	rlpSubstate, err := i.db.decode(data.value, block)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode data into rlp block: %v, tx %v; %w", block, tx, err)
+	}
+
+	if !touches(rlpSubstate, i.addr) {
+		return nil, nil
+	}
+
+	return i.db.toSubstate(rlpSubstate, block, tx)
+}
+
+func (i *addressSubstateIterator) start(_ int) {
+	i.wg.Add(1)
+
+	go func() {
+		defer func() {
+			close(i.resultCh)
+			i.wg.Done()
+		}()
+
+		for _, segment := range i.segments {
+			if !i.iterateSegment(segment) {
+				return
+			}
+		}
+	}()
+}
+
+// iterateSegment sends all substates of segment touching the address to resultCh.
+// It returns false if the iteration failed or was stopped.
+func (i *addressSubstateIterator) iterateSegment(segment indexSegment) bool {
+	iter := i.db.backend.NewIterator(addressKeyRange(i.addr, segment), i.db.ro)
+	defer iter.Release()
+
+	i.useIndex = segment.useIndex
+	for iter.Next() {
+		key := make([]byte, len(iter.Key()))
+		copy(key, iter.Key())
+		value := make([]byte, len(iter.Value()))
+		copy(value, iter.Value())
+
+		ss, err := i.decode(rawEntry{key, value})
+		if err != nil {
+			i.err = err
+			return false
+		}
+		if ss == nil {
+			continue
+		}
+
+		select {
+		case <-i.stopCh:
+			return false
+		case i.resultCh <- ss:
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		i.err = err
+		return false
+	}
+	return true
+}
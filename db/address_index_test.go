@@ -0,0 +1,171 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestAddressIndexDBKey_EncodeDecode(t *testing.T) {
+	addr := types.Address{0x12}
+	key := AddressIndexDBKey(addr, 10, 4)
+
+	gotAddr, block, tx, err := DecodeAddressIndexDBKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotAddr != addr || block != 10 || tx != 4 {
+		t.Fatalf("unexpected decoded key\ngot: %v %v_%v\nwant: %v 10_4", gotAddr, block, tx, addr)
+	}
+}
+
+func TestSubstateDB_NewAddressSubstateIterator(t *testing.T) {
+	indexed, err := newSubstateDB(t.TempDir()+"indexed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed.EnableIndexes(AddressIndex)
+
+	scanned, err := newSubstateDB(t.TempDir()+"scanned-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{0xcc}
+	touching := newTestSubstate(2, 1)
+	touching.InputSubstate[contract] = substate.NewAccount(0, big.NewInt(0), []byte{1})
+	created := newTestSubstate(4, 0)
+	created.Message.To = nil
+	created.Result.ContractAddress = contract
+
+	for _, db := range []*substateDB{indexed, scanned} {
+		putTestSubstates(t, db, newTestSubstate(1, 0), touching, newTestSubstate(3, 0), created, newTestSubstate(5, 0))
+	}
+
+	for name, db := range map[string]*substateDB{"indexed": indexed, "scanned": scanned} {
+		t.Run(name, func(t *testing.T) {
+			iter := db.NewAddressSubstateIterator(contract, 0, 10)
+			defer iter.Release()
+
+			var got [][2]int
+			for iter.Next() {
+				got = append(got, [2]int{int(iter.Value().Block), iter.Value().Transaction})
+			}
+			if err := iter.Error(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := [][2]int{{2, 1}, {4, 0}}
+			if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+				t.Fatalf("unexpected substates\ngot: %v\nwant: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestSubstateDB_BuildAddressIndex(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, db, newTestSubstate(1, 0), newTestSubstate(2, 0), newTestSubstate(3, 0))
+
+	if err = db.BuildAddressIndex(1, 2, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	coverage, err := db.getIndexCoverage(AddressIndexCoverageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (indexCoverage{first: 1, last: 2, valid: true}); coverage != want {
+		t.Fatalf("unexpected coverage\ngot: %+v\nwant: %+v", coverage, want)
+	}
+	if has, err := db.Has(AddressIndexDBKey(types.Address{2}, 3, 0)); err != nil || has {
+		t.Fatalf("block 3 must not be indexed; %v", err)
+	}
+
+	// block 3 is outside the coverage and is scanned
+	if got := addressSubstateBlocks(t, db, types.Address{2}, 0, 10); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("unexpected blocks\ngot: %v\nwant: [1 2 3]", got)
+	}
+}
+
+func TestSubstateDB_AddressIndexCoverage(t *testing.T) {
+	path := t.TempDir() + "test-db"
+	db, err := newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// block 1 is recorded before the index is enabled
+	putTestSubstates(t, db, newTestSubstate(1, 0))
+	db.EnableIndexes(AddressIndex)
+	putTestSubstates(t, db, newTestSubstate(2, 0), newTestSubstate(2, 1), newTestSubstate(4, 0))
+	if err = db.PutSubstates(newTestSubstate(5, 0), newTestSubstate(6, 0)); err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, db, newTestSubstate(3, 0))
+
+	coverage, err := db.getIndexCoverage(AddressIndexCoverageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (indexCoverage{first: 2, last: 6, valid: true}); coverage != want {
+		t.Fatalf("unexpected coverage\ngot: %+v\nwant: %+v", coverage, want)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the index is used without being enabled
+	putTestSubstates(t, db, newTestSubstate(8, 0))
+	if err = db.Delete(AddressIndexDBKey(types.Address{2}, 4, 0)); err != nil {
+		t.Fatal(err)
+	}
+
+	// block 4 is missing from the index, blocks 1 and 8 are scanned
+	want := []uint64{1, 2, 2, 3, 5, 6, 8}
+	got := addressSubstateBlocks(t, db, types.Address{2}, 0, 10)
+	if len(got) != len(want) {
+		t.Fatalf("unexpected blocks\ngot: %v\nwant: %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected blocks\ngot: %v\nwant: %v", got, want)
+		}
+	}
+
+	// block 9 cannot extend the coverage over unindexed block 8
+	db.EnableIndexes(AddressIndex)
+	putTestSubstates(t, db, newTestSubstate(9, 0))
+	if coverage, err = db.getIndexCoverage(AddressIndexCoverageKey); err != nil {
+		t.Fatal(err)
+	}
+	if want := (indexCoverage{first: 2, last: 6, valid: true}); coverage != want {
+		t.Fatalf("unexpected coverage\ngot: %+v\nwant: %+v", coverage, want)
+	}
+}
+
+// addressSubstateBlocks returns blocks of all substates touching addr between first and last block.
+func addressSubstateBlocks(t *testing.T, db SubstateDB, addr types.Address, first, last uint64) []uint64 {
+	iter := db.NewAddressSubstateIterator(addr, first, last)
+	defer iter.Release()
+
+	var blocks []uint64
+	for iter.Next() {
+		blocks = append(blocks, iter.Value().Block)
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return blocks
+}
@@ -0,0 +1,180 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/Fantom-foundation/Substate/rlp"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const BlockHashDBPrefix = "1b" // BlockHashDBPrefix + block (64-bit) -> hash
+
+// BlockHashWindow is the number of most recent blocks accessible by the BLOCKHASH opcode.
+const BlockHashWindow = 256
+
+// states of substateDB.blockHashTable
+const (
+	blockHashTableUnknown uint32 = iota // BlockHashTableKey was not read yet
+	blockHashTableAbsent
+	blockHashTablePresent
+)
+
+// GetBlockHash returns hash of given block from the block-hash table.
+func (db *substateDB) GetBlockHash(block uint64) (types.Hash, error) {
+	val, err := db.Get(BlockHashDBKey(block))
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("cannot get hash of block %v; %w", block, err)
+	}
+	return types.BytesToHash(val), nil
+}
+
+// PutBlockHash inserts hash of given block into the block-hash table.
+func (db *substateDB) PutBlockHash(block uint64, hash types.Hash) error {
+	if err := db.markBlockHashTable(db); err != nil {
+		return err
+	}
+	return putBlockHash(db, block, hash)
+}
+
+// BackfillBlockHashes moves inline BlockHashes of all substates between first and last block
+// into the block-hash table. Substates themselves are not rewritten.
+func (db *substateDB) BackfillBlockHashes(first, last uint64, workers int) error {
+	pool := &SubstateTaskPool{
+		Name: "backfill-block-hashes",
+		TaskFunc: func(_ uint64, _ int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			return putBlockHashes(db, ss)
+		},
+
+		First: first,
+		Last:  last,
+
+		Workers: workers,
+		DB:      db,
+	}
+
+	if err := db.markBlockHashTable(db); err != nil {
+		return err
+	}
+	return pool.Execute()
+}
+
+// hasBlockHashTable returns true if db contains the BlockHashTableKey marker.
+// Presence of the marker is read once and cached by the handle.
+func (db *substateDB) hasBlockHashTable() (bool, error) {
+	switch db.blockHashTable.Load() {
+	case blockHashTablePresent:
+		return true, nil
+	case blockHashTableAbsent:
+		return false, nil
+	}
+
+	found, err := db.Has([]byte(BlockHashTableKey))
+	if err != nil {
+		return false, fmt.Errorf("cannot get block-hash table marker; %w", err)
+	}
+	if found {
+		db.blockHashTable.Store(blockHashTablePresent)
+	} else {
+		db.blockHashTable.CompareAndSwap(blockHashTableUnknown, blockHashTableAbsent)
+	}
+	return found, nil
+}
+
+// markBlockHashTable writes the BlockHashTableKey marker into w.
+func (db *substateDB) markBlockHashTable(w KeyValueWriter) error {
+	if db.blockHashTable.Load() == blockHashTablePresent {
+		return nil
+	}
+	if err := w.Put([]byte(BlockHashTableKey), []byte{}); err != nil {
+		return fmt.Errorf("cannot put block-hash table marker; %w", err)
+	}
+	db.blockHashTable.Store(blockHashTablePresent)
+	return nil
+}
+
+// toSubstate converts r into substate.Substate. If db has a block-hash table, substates recorded
+// without inline BlockHashes get all hashes of the BLOCKHASH window before their block from the table.
+func (db *substateDB) toSubstate(r *rlp.RLP, block uint64, tx int) (*substate.Substate, error) {
+	ss, err := r.ToSubstate(db.GetCode, block, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// inline hashes are kept for substates recorded before the block-hash table
+	if ss.Env == nil || len(ss.Env.BlockHashes) > 0 {
+		return ss, nil
+	}
+	if found, err := db.hasBlockHashTable(); err != nil || !found {
+		return ss, err
+	}
+
+	if err = db.fillBlockHashes(ss.Env.BlockHashes, block); err != nil {
+		return nil, fmt.Errorf("cannot resolve block hashes of block: %v, tx %v; %w", block, tx, err)
+	}
+	return ss, nil
+}
+
+// fillBlockHashes adds all stored hashes of blocks within the BLOCKHASH window before block into hashes.
+func (db *substateDB) fillBlockHashes(hashes map[uint64]types.Hash, block uint64) error {
+	if block == 0 {
+		return nil
+	}
+
+	var first uint64
+	if block > BlockHashWindow {
+		first = block - BlockHashWindow
+	}
+
+	iter := db.backend.NewIterator(&util.Range{Start: BlockHashDBKey(first), Limit: BlockHashDBKey(block)}, db.ro)
+	defer iter.Release()
+
+	for iter.Next() {
+		number, err := DecodeBlockHashDBKey(iter.Key())
+		if err != nil {
+			return fmt.Errorf("invalid block hash key: %v; %w", iter.Key(), err)
+		}
+		hashes[number] = types.BytesToHash(iter.Value())
+	}
+	return iter.Error()
+}
+
+func putBlockHashes(w KeyValueWriter, ss *substate.Substate) error {
+	if ss.Env == nil {
+		return nil
+	}
+	for block, hash := range ss.Env.BlockHashes {
+		if err := putBlockHash(w, block, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func putBlockHash(w KeyValueWriter, block uint64, hash types.Hash) error {
+	return w.Put(BlockHashDBKey(block), hash.Bytes())
+}
+
+// BlockHashDBKey returns BlockHashDBPrefix with appended
+// block number creating key used in baseDB for the block-hash table.
+func BlockHashDBKey(block uint64) []byte {
+	return append([]byte(BlockHashDBPrefix), BlockToBytes(block)...)
+}
+
+// DecodeBlockHashDBKey decodes key created by BlockHashDBKey back to block number.
+func DecodeBlockHashDBKey(key []byte) (block uint64, err error) {
+	prefix := BlockHashDBPrefix
+	if len(key) != len(prefix)+8 {
+		err = fmt.Errorf("invalid length of block hash key: %v", len(key))
+		return
+	}
+	if p := string(key[:len(prefix)]); p != prefix {
+		err = fmt.Errorf("invalid prefix of block hash key: %#x", p)
+		return
+	}
+	block = binary.BigEndian.Uint64(key[len(prefix):])
+	return
+}
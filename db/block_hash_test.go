@@ -0,0 +1,151 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/rlp"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestBlockHashDBKey_EncodeDecode(t *testing.T) {
+	block, err := DecodeBlockHashDBKey(BlockHashDBKey(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != 42 {
+		t.Fatalf("unexpected block\ngot: %v\nwant: 42", block)
+	}
+}
+
+func TestSubstateDB_BlockHashIndexStripsAndResolvesHashes(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.EnableIndexes(BlockHashIndex)
+
+	first := newTestSubstate(300, 0)
+	first.Env.BlockHashes = map[uint64]types.Hash{10: {0x10}, 299: {0x29}}
+	second := newTestSubstate(300, 1)
+	second.Env.BlockHashes = map[uint64]types.Hash{298: {0x28}}
+	putTestSubstates(t, db, first, second)
+
+	val, err := db.Get(SubstateDBKey(300, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rlp.Decode(val)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Env.BlockHashes) != 0 {
+		t.Fatalf("block hashes must not be stored inline, got %v", r.Env.BlockHashes)
+	}
+
+	ss, err := db.GetSubstate(300, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// block 10 is outside the window of block 300
+	want := map[uint64]types.Hash{298: {0x28}, 299: {0x29}}
+	if len(ss.Env.BlockHashes) != len(want) {
+		t.Fatalf("unexpected block hashes\ngot: %v\nwant: %v", ss.Env.BlockHashes, want)
+	}
+	for block, hash := range want {
+		if ss.Env.BlockHashes[block] != hash {
+			t.Fatalf("unexpected hash of block %v\ngot: %v\nwant: %v", block, ss.Env.BlockHashes[block], hash)
+		}
+	}
+
+	hash, err := db.GetBlockHash(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != (types.Hash{0x10}) {
+		t.Fatalf("unexpected hash of block 10: %v", hash)
+	}
+}
+
+func TestSubstateDB_InlineBlockHashesArePreferred(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.PutBlockHash(4, types.Hash{0x04}); err != nil {
+		t.Fatal(err)
+	}
+
+	inline := newTestSubstate(5, 0)
+	inline.Env.BlockHashes = map[uint64]types.Hash{3: {0x03}}
+	putTestSubstates(t, db, inline, newTestSubstate(5, 1))
+
+	ss, err := db.GetSubstate(5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss.Env.BlockHashes) != 1 || ss.Env.BlockHashes[3] != (types.Hash{0x03}) {
+		t.Fatalf("unexpected inline block hashes %v", ss.Env.BlockHashes)
+	}
+
+	ss, err = db.GetSubstate(5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ss.Env.BlockHashes) != 1 || ss.Env.BlockHashes[4] != (types.Hash{0x04}) {
+		t.Fatalf("unexpected resolved block hashes %v", ss.Env.BlockHashes)
+	}
+}
+
+func TestSubstateDB_BackfillBlockHashes(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newTestSubstate(2, 0)
+	ss.Env.BlockHashes = map[uint64]types.Hash{1: {0x01}}
+	putTestSubstates(t, db, ss)
+
+	if _, err = db.GetBlockHash(1); err == nil {
+		t.Fatal("hash must not be stored without the BlockHashIndex")
+	}
+	if err = db.BackfillBlockHashes(0, 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := db.GetBlockHash(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != (types.Hash{0x01}) {
+		t.Fatalf("unexpected hash of block 1: %v", hash)
+	}
+}
+
+func TestSubstateDB_EmptyBlockHashesRoundTrip(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// hash is stored without marking the block-hash table
+	if err = db.Put(BlockHashDBKey(4), types.Hash{0x04}.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := newTestSubstate(5, 0)
+	want.Env.BlockHashes = map[uint64]types.Hash{}
+	// decoded message carries a zero blob fee cap
+	want.Message.BlobGasFeeCap = big.NewInt(0)
+	putTestSubstates(t, db, want)
+
+	got, err := db.GetSubstate(5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Env.BlockHashes) != 0 {
+		t.Fatalf("block hashes must not be resolved without the block-hash table, got %v", got.Env.BlockHashes)
+	}
+	if err = got.Equal(want); err != nil {
+		t.Fatalf("substates are not equal; %v", err)
+	}
+}
@@ -27,6 +27,12 @@ type CodeDB interface {
 
 	// DeleteCode deletes the code for given hash.
 	DeleteCode(types.Hash) error
+
+	// NewCodeIterator returns iterator over all codes within the DB ordered by their hash.
+	NewCodeIterator() Iterator[*CodeEntry]
+
+	// GetCodeReport returns statistics of all codes within the DB.
+	GetCodeReport() (*CodeReport, error)
 }
 
 // NewDefaultCodeDB creates new instance of CodeDB with default options.
@@ -93,9 +99,13 @@ func (db *codeDB) GetCode(codeHash types.Hash) ([]byte, error) {
 
 // PutCode creates hash for given code and inserts it into the baseDB.
 func (db *codeDB) PutCode(code []byte) error {
+	return putCode(db, code)
+}
+
+func putCode(w KeyValueWriter, code []byte) error {
 	codeHash := hash.Keccak256Hash(code)
 	key := CodeDBKey(codeHash)
-	err := db.Put(key, code)
+	err := w.Put(key, code)
 	if err != nil {
 		return fmt.Errorf("cannot put code %s: %w", codeHash, err)
 	}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/Fantom-foundation/Substate/rlp"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+var emptyCodeHash = hash.Keccak256Hash(nil)
+
+// CodeHashChange records the code hash of an account observed since given block and tx.
+type CodeHashChange struct {
+	Block       uint64
+	Transaction int
+	CodeHash    types.Hash
+}
+
+// GetCodeHashHistory returns code hashes of addr between first and last block (including first and last).
+// A code hash is returned for the first transaction touching addr and for every transaction changing it.
+// If ddb is not nil, destructions of addr are returned as the empty code hash.
+// The AddressIndex is used within its recorded coverage.
+func (db *substateDB) GetCodeHashHistory(ddb *DestroyedAccountDB, addr types.Address, first, last uint64) ([]CodeHashChange, error) {
+	var history []CodeHashChange
+	err := db.walkAccountHistory(ddb, addr, first, last, func(ss *substate.Substate, acc *substate.Account, _, _ bool) {
+		codeHash := acc.CodeHash()
+		if n := len(history); n > 0 && history[n-1].CodeHash == codeHash {
+			return
+		}
+		history = append(history, CodeHashChange{Block: ss.Block, Transaction: ss.Transaction, CodeHash: codeHash})
+	})
+	return history, err
+}
+
+// GetCodeByAddress returns code of addr after all transactions of given block.
+// Substates are searched backwards from the block for the last one containing addr,
+// the AddressIndex is used within its recorded coverage. If ddb is not nil and addr was destroyed
+// by that substate, empty code is returned. If addr is not found, leveldb.ErrNotFound is returned.
+func (db *substateDB) GetCodeByAddress(ddb *DestroyedAccountDB, addr types.Address, block uint64) ([]byte, error) {
+	segments, err := db.indexSegments(AddressIndexCoverageKey, 0, block)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		acc, b, tx, err := db.findLastAccount(addr, segments[i])
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			continue
+		}
+		destroyed, _, err := isDestroyed(ddb, addr, b, tx)
+		if err != nil {
+			return nil, err
+		}
+		if destroyed || acc.CodeHash == emptyCodeHash {
+			return []byte{}, nil
+		}
+		return db.GetCode(acc.CodeHash)
+	}
+
+	return nil, fmt.Errorf("cannot find account %s until block %v; %w", addr, block, leveldb.ErrNotFound)
+}
+
+// findLastAccount returns account of addr within the last substate of segment containing addr
+// together with block and tx of the substate, or nil if no substate of segment contains addr.
+func (db *substateDB) findLastAccount(addr types.Address, segment indexSegment) (*rlp.SubstateAccountRLP, uint64, int, error) {
+	iter := db.backend.NewIterator(addressKeyRange(addr, segment), db.ro)
+	defer iter.Release()
+
+	for ok := iter.Last(); ok; ok = iter.Prev() {
+		var (
+			b     uint64
+			tx    int
+			err   error
+			value = iter.Value()
+		)
+		if segment.useIndex {
+			_, b, tx, err = DecodeAddressIndexDBKey(iter.Key())
+			if err != nil {
+				return nil, 0, 0, fmt.Errorf("invalid address index key: %v; %w", iter.Key(), err)
+			}
+			value, err = db.Get(SubstateDBKey(b, tx))
+			if err != nil {
+				return nil, 0, 0, fmt.Errorf("cannot get substate block: %v, tx: %v from db; %w", b, tx, err)
+			}
+		} else if b, tx, err = DecodeSubstateDBKey(iter.Key()); err != nil {
+			return nil, 0, 0, fmt.Errorf("invalid substate key: %v; %w", iter.Key(), err)
+		}
+
+		rlpSubstate, err := db.decode(value, b)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("cannot decode substate of %s; %w", addr, err)
+		}
+
+		acc := findAccount(rlpSubstate.OutputSubstate, addr)
+		if acc == nil {
+			acc = findAccount(rlpSubstate.InputSubstate, addr)
+		}
+		if acc != nil {
+			return acc, b, tx, nil
+		}
+	}
+
+	return nil, 0, 0, iter.Error()
+}
+
+// findAccount returns account of addr within ws or nil if ws does not contain addr.
+func findAccount(ws rlp.WorldState, addr types.Address) *rlp.SubstateAccountRLP {
+	for i, a := range ws.Addresses {
+		if a == addr {
+			return ws.Accounts[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+func TestSubstateDB_GetCodeHashHistory(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{0xcc}
+	putTestSubstates(t, db, codeChangingSubstates(contract)...)
+
+	history, err := db.GetCodeHashHistory(nil, contract, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []CodeHashChange{
+		{Block: 2, Transaction: 0, CodeHash: hash.Keccak256Hash([]byte{1})},
+		{Block: 4, Transaction: 0, CodeHash: hash.Keccak256Hash([]byte{2})},
+	}
+	if len(history) != len(want) || history[0] != want[0] || history[1] != want[1] {
+		t.Fatalf("unexpected history\ngot: %v\nwant: %v", history, want)
+	}
+}
+
+func TestSubstateDB_GetCodeByAddress(t *testing.T) {
+	indexed, err := newSubstateDB(t.TempDir()+"indexed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed.EnableIndexes(AddressIndex)
+
+	scanned, err := newSubstateDB(t.TempDir()+"scanned-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partial, err := newSubstateDB(t.TempDir()+"partial-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{0xcc}
+	for _, db := range []*substateDB{indexed, scanned, partial} {
+		putTestSubstates(t, db, codeChangingSubstates(contract)...)
+	}
+	// blocks 2 and 4 are outside the coverage and are scanned
+	if err = partial.BuildAddressIndex(3, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, db := range map[string]*substateDB{"indexed": indexed, "scanned": scanned, "partial": partial} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := db.GetCodeByAddress(nil, contract, 1); !errors.Is(err, leveldb.ErrNotFound) {
+				t.Fatalf("unexpected error\ngot: %v\nwant: %v", err, leveldb.ErrNotFound)
+			}
+
+			for block, want := range map[uint64][]byte{2: {1}, 3: {1}, 4: {2}, 10: {2}} {
+				code, err := db.GetCodeByAddress(nil, contract, block)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(code, want) {
+					t.Fatalf("unexpected code at block %v\ngot: %v\nwant: %v", block, code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSubstateDB_CodeHistoryOfDestroyedAccount(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{0xcc}
+	destroyed := newTestSubstate(5, 0)
+	destroyed.InputSubstate[contract] = substate.NewAccount(1, big.NewInt(0), []byte{2})
+	putTestSubstates(t, db, append(codeChangingSubstates(contract), destroyed)...)
+	if err = ddb.SetDestroyedAccounts(5, 0, []types.Address{contract}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := db.GetCodeHashHistory(ddb, contract, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CodeHashChange{
+		{Block: 2, Transaction: 0, CodeHash: hash.Keccak256Hash([]byte{1})},
+		{Block: 4, Transaction: 0, CodeHash: hash.Keccak256Hash([]byte{2})},
+		{Block: 5, Transaction: 0, CodeHash: hash.Keccak256Hash(nil)},
+	}
+	if len(history) != len(want) || history[0] != want[0] || history[1] != want[1] || history[2] != want[2] {
+		t.Fatalf("unexpected history\ngot: %v\nwant: %v", history, want)
+	}
+
+	code, err := db.GetCodeByAddress(ddb, contract, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 0 {
+		t.Fatalf("unexpected code of destroyed account: %v", code)
+	}
+
+	// destructions are not known without ddb
+	code, err = db.GetCodeByAddress(nil, contract, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, []byte{2}) {
+		t.Fatalf("unexpected code\ngot: %v\nwant: %v", code, []byte{2})
+	}
+}
+
+// codeChangingSubstates returns substates which deploy code {1} to addr in block 2,
+// read it in block 3 and replace it by code {2} in block 4.
+func codeChangingSubstates(addr types.Address) []*substate.Substate {
+	deploy := newTestSubstate(2, 0)
+	deploy.OutputSubstate[addr] = substate.NewAccount(1, big.NewInt(0), []byte{1})
+
+	read := newTestSubstate(3, 0)
+	read.InputSubstate[addr] = substate.NewAccount(1, big.NewInt(0), []byte{1})
+	read.OutputSubstate[addr] = substate.NewAccount(1, big.NewInt(0), []byte{1})
+
+	replace := newTestSubstate(4, 0)
+	replace.InputSubstate[addr] = substate.NewAccount(1, big.NewInt(0), []byte{1})
+	replace.OutputSubstate[addr] = substate.NewAccount(1, big.NewInt(0), []byte{2})
+
+	return []*substate.Substate{newTestSubstate(1, 0), deploy, read, replace}
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// CodeEntry is a code stored within CodeDB together with its hash.
+type CodeEntry struct {
+	Hash types.Hash
+	Code []byte
+}
+
+// NewCodeIterator returns iterator which iterates over all codes.
+// Note: Besides deployed bytecode the DB also contains init code of contract creations.
+func (db *codeDB) NewCodeIterator() Iterator[*CodeEntry] {
+	iter := newCodeIterator(db)
+
+	iter.start(0)
+
+	return iter
+}
+
+func newCodeIterator(db *codeDB) *codeIterator {
+	r := util.BytesPrefix([]byte(CodeDBPrefix))
+
+	return &codeIterator{
+		iterator: newIterator[*CodeEntry](db.backend.NewIterator(r, db.ro)),
+	}
+}
+
+type codeIterator struct {
+	iterator[*CodeEntry]
+}
+
+func (i *codeIterator) decode(data rawEntry) (*CodeEntry, error) {
+	codeHash, err := DecodeCodeDBKey(data.key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid code key: %v; %w", data.key, err)
+	}
+
+	return &CodeEntry{Hash: codeHash, Code: data.value}, nil
+}
+
+func (i *codeIterator) start(_ int) {
+	i.wg.Add(1)
+
+	go func() {
+		defer func() {
+			close(i.resultCh)
+			i.wg.Done()
+		}()
+
+		for i.iter.Next() {
+			key := make([]byte, len(i.iter.Key()))
+			copy(key, i.iter.Key())
+			value := make([]byte, len(i.iter.Value()))
+			copy(value, i.iter.Value())
+
+			entry, err := i.decode(rawEntry{key, value})
+			if err != nil {
+				i.err = err
+				return
+			}
+
+			select {
+			case <-i.stopCh:
+				return
+			case i.resultCh <- entry:
+			}
+		}
+	}()
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+const (
+	// MaxCodeSize is the maximum size of deployed bytecode introduced by EIP-170.
+	MaxCodeSize = 24576
+
+	// CodeSizeBucket is the width of a bucket within CodeReport.SizeHistogram in bytes.
+	CodeSizeBucket = 1024
+)
+
+var (
+	// minimal proxy contract (EIP-1167) surrounding the 20-byte implementation address
+	minimalProxyPrefix = types.Hex2Bytes("363d3d373d3d3d363d73")
+	minimalProxySuffix = types.Hex2Bytes("5af43d82803e903d91602b57fd5bf3")
+)
+
+// CodeReport contains statistics of codes stored in a CodeDB.
+type CodeReport struct {
+	NumCodes  uint64 // number of non-empty codes
+	TotalSize uint64 // size of all codes in bytes
+
+	// SizeHistogram counts codes by size, bucket i holds codes of size
+	// within [i*CodeSizeBucket, (i+1)*CodeSizeBucket).
+	SizeHistogram []uint64
+
+	// OversizedCodes holds hashes of codes larger than MaxCodeSize.
+	// Note: init code of contract creations may legally exceed MaxCodeSize.
+	OversizedCodes []types.Hash
+
+	// NumMinimalProxies is the number of EIP-1167 minimal proxy contracts.
+	NumMinimalProxies uint64
+
+	// NearDuplicates groups hashes of codes which are equal after their metadata
+	// is stripped by StripCodeMetadata. The key is the hash of the stripped code.
+	// Only groups with more than one code are reported.
+	NearDuplicates map[types.Hash][]types.Hash
+}
+
+// GetCodeReport iterates over all codes within the DB and returns their statistics.
+func (db *codeDB) GetCodeReport() (*CodeReport, error) {
+	iter := db.NewCodeIterator()
+	defer iter.Release()
+
+	report := &CodeReport{
+		NearDuplicates: make(map[types.Hash][]types.Hash),
+	}
+	for iter.Next() {
+		report.add(iter.Value())
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("cannot iterate codes; %w", err)
+	}
+
+	for strippedHash, group := range report.NearDuplicates {
+		if len(group) < 2 {
+			delete(report.NearDuplicates, strippedHash)
+			continue
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].Compare(group[j]) < 0 })
+	}
+
+	return report, nil
+}
+
+func (r *CodeReport) add(entry *CodeEntry) {
+	size := len(entry.Code)
+	if size == 0 {
+		return
+	}
+
+	r.NumCodes++
+	r.TotalSize += uint64(size)
+
+	bucket := size / CodeSizeBucket
+	for len(r.SizeHistogram) <= bucket {
+		r.SizeHistogram = append(r.SizeHistogram, 0)
+	}
+	r.SizeHistogram[bucket]++
+
+	if size > MaxCodeSize {
+		r.OversizedCodes = append(r.OversizedCodes, entry.Hash)
+	}
+
+	if IsMinimalProxy(entry.Code) {
+		r.NumMinimalProxies++
+	}
+
+	strippedHash := StrippedCodeHash(entry.Code)
+	r.NearDuplicates[strippedHash] = append(r.NearDuplicates[strippedHash], entry.Hash)
+}
+
+// IsMinimalProxy returns true if code is an EIP-1167 minimal proxy contract.
+func IsMinimalProxy(code []byte) bool {
+	return len(code) == len(minimalProxyPrefix)+types.AddressLength+len(minimalProxySuffix) &&
+		bytes.HasPrefix(code, minimalProxyPrefix) &&
+		bytes.HasSuffix(code, minimalProxySuffix)
+}
+
+// StripCodeMetadata returns code without the CBOR-encoded metadata appended by the Solidity compiler.
+// Implementation address of EIP-1167 minimal proxies is zeroed, so all such proxies share the same stripped code.
+// If code carries no recognizable metadata, it is returned unchanged.
+func StripCodeMetadata(code []byte) []byte {
+	if IsMinimalProxy(code) {
+		stripped := make([]byte, len(code))
+		copy(stripped, minimalProxyPrefix)
+		copy(stripped[len(code)-len(minimalProxySuffix):], minimalProxySuffix)
+		return stripped
+	}
+
+	// last two bytes hold the big-endian length of the metadata
+	if len(code) < 2 {
+		return code
+	}
+	metadataLength := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	start := len(code) - 2 - metadataLength
+	if metadataLength == 0 || start < 0 {
+		return code
+	}
+
+	// metadata is a CBOR map (major type 5)
+	if code[start]&0xe0 != 0xa0 {
+		return code
+	}
+	return code[:start]
+}
+
+// StrippedCodeHash returns Keccak256 hash of code stripped by StripCodeMetadata.
+func StrippedCodeHash(code []byte) types.Hash {
+	return hash.Keccak256Hash(StripCodeMetadata(code))
+}
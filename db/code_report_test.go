@@ -0,0 +1,109 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+func TestCodeDB_NewCodeIterator(t *testing.T) {
+	db, err := newCodeDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	codes := map[types.Hash][]byte{}
+	for _, code := range [][]byte{{1}, {2, 3}, {4, 5, 6}} {
+		if err = db.PutCode(code); err != nil {
+			t.Fatal(err)
+		}
+		codes[hash.Keccak256Hash(code)] = code
+	}
+
+	iter := db.NewCodeIterator()
+	defer iter.Release()
+
+	var count int
+	for iter.Next() {
+		entry := iter.Value()
+		if !bytes.Equal(codes[entry.Hash], entry.Code) {
+			t.Fatalf("unexpected code of %v\ngot: %v\nwant: %v", entry.Hash, entry.Code, codes[entry.Hash])
+		}
+		count++
+	}
+	if err = iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != len(codes) {
+		t.Fatalf("unexpected number of codes\ngot: %v\nwant: %v", count, len(codes))
+	}
+}
+
+func TestCodeDB_GetCodeReport(t *testing.T) {
+	db, err := newCodeDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proxyA := minimalProxy(types.Address{1})
+	proxyB := minimalProxy(types.Address{2})
+	// same bytecode followed by different CBOR metadata (a1 = map with one item)
+	compiledA := append([]byte{0x60, 0x80, 0xa1, 0x01}, 0x00, 0x02)
+	compiledB := append([]byte{0x60, 0x80, 0xa1, 0x02}, 0x00, 0x02)
+	oversized := make([]byte, MaxCodeSize+1)
+
+	for _, code := range [][]byte{proxyA, proxyB, compiledA, compiledB, oversized} {
+		if err = db.PutCode(code); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := db.GetCodeReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.NumCodes != 5 {
+		t.Fatalf("unexpected number of codes\ngot: %v\nwant: 5", report.NumCodes)
+	}
+	if want := uint64(2*len(proxyA) + 2*len(compiledA) + len(oversized)); report.TotalSize != want {
+		t.Fatalf("unexpected total size\ngot: %v\nwant: %v", report.TotalSize, want)
+	}
+	if len(report.SizeHistogram) != MaxCodeSize/CodeSizeBucket+1 || report.SizeHistogram[0] != 4 || report.SizeHistogram[MaxCodeSize/CodeSizeBucket] != 1 {
+		t.Fatalf("unexpected size histogram: %v", report.SizeHistogram)
+	}
+	if len(report.OversizedCodes) != 1 || report.OversizedCodes[0] != hash.Keccak256Hash(oversized) {
+		t.Fatalf("unexpected oversized codes: %v", report.OversizedCodes)
+	}
+	if report.NumMinimalProxies != 2 {
+		t.Fatalf("unexpected number of minimal proxies\ngot: %v\nwant: 2", report.NumMinimalProxies)
+	}
+	if len(report.NearDuplicates) != 2 {
+		t.Fatalf("unexpected number of near duplicate groups\ngot: %v\nwant: 2", len(report.NearDuplicates))
+	}
+	if group := report.NearDuplicates[StrippedCodeHash(compiledA)]; len(group) != 2 {
+		t.Fatalf("compiled codes are not reported as near duplicates: %v", group)
+	}
+}
+
+func TestStripCodeMetadata(t *testing.T) {
+	code := []byte{0x60, 0x80, 0xa1, 0x01, 0x00, 0x02}
+	if got := StripCodeMetadata(code); !bytes.Equal(got, []byte{0x60, 0x80}) {
+		t.Fatalf("unexpected stripped code\ngot: %x\nwant: 6080", got)
+	}
+
+	// last two bytes do not point to a CBOR map
+	code = []byte{0x60, 0x80, 0x60, 0x00, 0x02}
+	if got := StripCodeMetadata(code); !bytes.Equal(got, code) {
+		t.Fatalf("code without metadata must not be changed\ngot: %x\nwant: %x", got, code)
+	}
+}
+
+func minimalProxy(implementation types.Address) []byte {
+	code := append([]byte{}, minimalProxyPrefix...)
+	code = append(code, implementation[:]...)
+	return append(code, minimalProxySuffix...)
+}
@@ -0,0 +1,265 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+// InconsistencyKind describes which part of an account is inconsistent.
+type InconsistencyKind int
+
+const (
+	// NonceMismatch means the nonce read by a transaction differs from the known nonce.
+	NonceMismatch InconsistencyKind = iota
+	// BalanceMismatch means the balance read by a transaction differs from the known balance.
+	BalanceMismatch
+	// CodeMismatch means the code read by a transaction differs from the known code.
+	CodeMismatch
+	// StorageMismatch means a storage slot read by a transaction differs from the known value.
+	StorageMismatch
+)
+
+func (k InconsistencyKind) String() string {
+	switch k {
+	case NonceMismatch:
+		return "nonce"
+	case BalanceMismatch:
+		return "balance"
+	case CodeMismatch:
+		return "code"
+	case StorageMismatch:
+		return "storage"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// Inconsistency is a value within the InputSubstate of a transaction which does not agree
+// with the OutputSubstates and InputSubstates of preceding transactions.
+type Inconsistency struct {
+	Block       uint64
+	Transaction int
+	Kind        InconsistencyKind
+	Address     types.Address
+	Slot        types.Hash // only set for StorageMismatch
+
+	Want string // value derived from preceding transactions, code is represented by its hash
+	Got  string // value within the InputSubstate
+
+	// Destroyed is true if the account was destroyed by a preceding transaction
+	// and is read again without being created.
+	Destroyed bool
+}
+
+func (i Inconsistency) String() string {
+	var suffix string
+	if i.Destroyed {
+		suffix = " (destroyed account)"
+	}
+	if i.Kind == StorageMismatch {
+		return fmt.Sprintf("%v_%v: %v of %s slot %s; want %v, got %v%v", i.Block, i.Transaction, i.Kind, i.Address, i.Slot, i.Want, i.Got, suffix)
+	}
+	return fmt.Sprintf("%v_%v: %v of %s; want %v, got %v%v", i.Block, i.Transaction, i.Kind, i.Address, i.Want, i.Got, suffix)
+}
+
+// ConsistencyOptions configures CheckSubstateConsistency.
+type ConsistencyOptions struct {
+	First uint64 // first checked block
+	Last  uint64 // last checked block (inclusive)
+
+	Workers int // number of workers decoding substates
+
+	// AcrossBlocks keeps the known state between blocks, so every transaction is checked
+	// against all preceding transactions within the range. Otherwise, the known state is reset
+	// at the start of each block which keeps memory usage bounded.
+	AcrossBlocks bool
+}
+
+// CheckSubstateConsistency checks that every account and slot within the InputSubstate of a transaction
+// agrees with what is known from preceding transactions. The known state is built from InputSubstates
+// and OutputSubstates of preceding transactions. If ddb is not nil, destroyed accounts are known to be empty.
+// Returned inconsistencies are ordered by block and transaction number.
+func CheckSubstateConsistency(db SubstateDB, ddb *DestroyedAccountDB, opt ConsistencyOptions) ([]Inconsistency, error) {
+	iter := db.NewSubstateIterator(int(opt.First), max(opt.Workers, 1))
+	defer iter.Release()
+
+	state := newKnownState()
+	currentBlock := opt.First
+
+	var found []Inconsistency
+	for iter.Next() {
+		ss := iter.Value()
+		if ss.Block > opt.Last {
+			break
+		}
+		if ss.Block != currentBlock && !opt.AcrossBlocks {
+			state = newKnownState()
+		}
+		currentBlock = ss.Block
+
+		found = append(found, state.check(ss)...)
+
+		var destroyed, resurrected []types.Address
+		if ddb != nil {
+			var err error
+			destroyed, resurrected, err = ddb.GetDestroyedAccounts(ss.Block, ss.Transaction)
+			if err != nil {
+				return nil, fmt.Errorf("cannot get destroyed accounts block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+			}
+		}
+		state.apply(ss, destroyed, resurrected)
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("cannot iterate substates; %w", err)
+	}
+	return found, nil
+}
+
+// knownState is the state of accounts derived from already checked transactions.
+type knownState struct {
+	accounts map[types.Address]*substate.Account
+
+	// cleared holds accounts whose storage is known to be empty except slots within accounts.
+	cleared map[types.Address]bool
+
+	// destroyed holds accounts which were destroyed and not created again.
+	destroyed map[types.Address]bool
+}
+
+func newKnownState() *knownState {
+	return &knownState{
+		accounts:  make(map[types.Address]*substate.Account),
+		cleared:   make(map[types.Address]bool),
+		destroyed: make(map[types.Address]bool),
+	}
+}
+
+// check returns inconsistencies between the InputSubstate of ss and the known state.
+func (s *knownState) check(ss *substate.Substate) []Inconsistency {
+	var inconsistencies []Inconsistency
+	report := func(kind InconsistencyKind, addr types.Address, slot types.Hash, want, got any) {
+		inconsistencies = append(inconsistencies, Inconsistency{
+			Block:       ss.Block,
+			Transaction: ss.Transaction,
+			Kind:        kind,
+			Address:     addr,
+			Slot:        slot,
+			Want:        fmt.Sprint(want),
+			Got:         fmt.Sprint(got),
+			Destroyed:   s.destroyed[addr],
+		})
+	}
+
+	for _, addr := range sortedAddresses(ss.InputSubstate) {
+		acc := ss.InputSubstate[addr]
+		known, found := s.accounts[addr]
+		if !found {
+			continue
+		}
+
+		if acc.Nonce != known.Nonce {
+			report(NonceMismatch, addr, types.Hash{}, known.Nonce, acc.Nonce)
+		}
+		if balanceOf(acc).Cmp(balanceOf(known)) != 0 {
+			report(BalanceMismatch, addr, types.Hash{}, balanceOf(known), balanceOf(acc))
+		}
+		if !bytes.Equal(acc.Code, known.Code) {
+			report(CodeMismatch, addr, types.Hash{}, hash.Keccak256Hash(known.Code), hash.Keccak256Hash(acc.Code))
+		}
+
+		for _, slot := range sortedSlots(acc.Storage) {
+			want, found := known.Storage[slot]
+			if !found && !s.cleared[addr] {
+				continue
+			}
+			if got := acc.Storage[slot]; got != want {
+				report(StorageMismatch, addr, slot, want, got)
+			}
+		}
+	}
+	return inconsistencies
+}
+
+// apply updates the known state by the InputSubstate and OutputSubstate of ss and given destructions.
+func (s *knownState) apply(ss *substate.Substate, destroyed, resurrected []types.Address) {
+	// values read for the first time become known
+	for addr, acc := range ss.InputSubstate {
+		known, found := s.accounts[addr]
+		if !found {
+			s.accounts[addr] = acc.Copy()
+			continue
+		}
+		for slot, value := range acc.Storage {
+			if _, found = known.Storage[slot]; !found {
+				known.Storage[slot] = value
+			}
+		}
+	}
+
+	for _, addr := range resurrected {
+		s.clear(addr)
+		delete(s.destroyed, addr)
+	}
+
+	for addr, acc := range ss.OutputSubstate {
+		known, found := s.accounts[addr]
+		if !found {
+			s.accounts[addr] = acc.Copy()
+		} else {
+			known.Nonce = acc.Nonce
+			known.Balance = acc.Balance
+			known.Code = acc.Code
+			for slot, value := range acc.Storage {
+				known.Storage[slot] = value
+			}
+		}
+
+		// a nonce or code means the account was created again
+		if acc.Nonce > 0 || len(acc.Code) > 0 {
+			delete(s.destroyed, addr)
+		}
+	}
+
+	for _, addr := range destroyed {
+		s.clear(addr)
+		s.destroyed[addr] = true
+	}
+}
+
+// clear makes addr a known empty account.
+func (s *knownState) clear(addr types.Address) {
+	s.accounts[addr] = substate.NewAccount(0, new(big.Int), nil)
+	s.cleared[addr] = true
+}
+
+func balanceOf(acc *substate.Account) *big.Int {
+	if acc.Balance == nil {
+		return new(big.Int)
+	}
+	return acc.Balance
+}
+
+func sortedAddresses(ws substate.WorldState) []types.Address {
+	addresses := make([]types.Address, 0, len(ws))
+	for addr := range ws {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+	return addresses
+}
+
+func sortedSlots(storage map[types.Hash]types.Hash) []types.Hash {
+	slots := make([]types.Hash, 0, len(storage))
+	for slot := range storage {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Compare(slots[j]) < 0 })
+	return slots
+}
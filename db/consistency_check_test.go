@@ -0,0 +1,92 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestCheckSubstateConsistency_ReportsMismatches(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := newTestSubstate(1, 0)
+	first.OutputSubstate[types.Address{2}].Storage[types.Hash{1}] = types.Hash{1}
+
+	// reads the output of the first transaction
+	consistent := newTestSubstate(1, 1)
+	consistent.InputSubstate = substate.WorldState{
+		types.Address{1}: substate.NewAccount(2, big.NewInt(90), nil),
+		types.Address{2}: substate.NewAccount(0, big.NewInt(10), nil),
+	}
+	consistent.InputSubstate[types.Address{2}].Storage[types.Hash{1}] = types.Hash{1}
+	consistent.OutputSubstate = substate.WorldState{}
+
+	inconsistent := newTestSubstate(1, 2)
+	inconsistent.InputSubstate = substate.WorldState{
+		types.Address{1}: substate.NewAccount(1, big.NewInt(90), nil),
+		types.Address{2}: substate.NewAccount(0, big.NewInt(10), nil),
+	}
+	inconsistent.InputSubstate[types.Address{2}].Storage[types.Hash{1}] = types.Hash{2}
+
+	putTestSubstates(t, db, first, consistent, inconsistent)
+
+	found, err := CheckSubstateConsistency(db, nil, ConsistencyOptions{First: 0, Last: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Inconsistency{
+		{Block: 1, Transaction: 2, Kind: NonceMismatch, Address: types.Address{1}, Want: "2", Got: "1"},
+		{Block: 1, Transaction: 2, Kind: StorageMismatch, Address: types.Address{2}, Slot: types.Hash{1}, Want: types.Hash{1}.String(), Got: types.Hash{2}.String()},
+	}
+	if len(found) != len(want) || found[0] != want[0] || found[1] != want[1] {
+		t.Fatalf("unexpected inconsistencies\ngot: %v\nwant: %v", found, want)
+	}
+}
+
+func TestCheckSubstateConsistency_DetectsReappearedDestroyedAccount(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{5}
+	destroying := newTestSubstate(1, 0)
+	destroying.InputSubstate[contract] = substate.NewAccount(1, big.NewInt(0), []byte{0xff})
+	if err = ddb.SetDestroyedAccounts(1, 0, []types.Address{contract}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	reading := newTestSubstate(2, 0)
+	reading.InputSubstate = substate.WorldState{
+		contract: substate.NewAccount(1, big.NewInt(0), []byte{0xff}),
+	}
+
+	putTestSubstates(t, db, destroying, reading)
+
+	// state is reset between blocks
+	found, err := CheckSubstateConsistency(db, ddb, ConsistencyOptions{First: 0, Last: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 0 {
+		t.Fatalf("unexpected inconsistencies: %v", found)
+	}
+
+	found, err = CheckSubstateConsistency(db, ddb, ConsistencyOptions{First: 0, Last: 10, AcrossBlocks: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found[0].Kind != NonceMismatch || found[1].Kind != CodeMismatch || !found[0].Destroyed {
+		t.Fatalf("unexpected inconsistencies: %v", found)
+	}
+}
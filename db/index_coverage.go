@@ -0,0 +1,183 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// coveredIndexes are optional indexes whose coverage is recorded in the DB under given metadata key.
+// Readers use these indexes only within their coverage and scan substates outside of it.
+var coveredIndexes = []struct {
+	flag        IndexFlags
+	coverageKey string
+}{
+	{AddressIndex, AddressIndexCoverageKey},
+	{LogIndex, LogIndexCoverageKey},
+}
+
+// coveredIndexFlags selects all coveredIndexes.
+const coveredIndexFlags = AddressIndex | LogIndex
+
+// indexCoverage is the block range in which all substates are inserted into an index.
+type indexCoverage struct {
+	first, last uint64
+	valid       bool // false if no block is covered
+}
+
+func (c indexCoverage) covers(block uint64) bool {
+	return c.valid && c.first <= block && block <= c.last
+}
+
+// getIndexCoverage returns the coverage recorded under key or an invalid coverage if none is recorded.
+func (db *substateDB) getIndexCoverage(key string) (indexCoverage, error) {
+	value, err := db.Get([]byte(key))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return indexCoverage{}, nil
+	}
+	if err != nil {
+		return indexCoverage{}, fmt.Errorf("cannot get index coverage %v; %w", key, err)
+	}
+	if len(value) != 16 {
+		return indexCoverage{}, fmt.Errorf("invalid length of index coverage %v: %v", key, len(value))
+	}
+	return indexCoverage{
+		first: binary.BigEndian.Uint64(value[0:8]),
+		last:  binary.BigEndian.Uint64(value[8:16]),
+		valid: true,
+	}, nil
+}
+
+func putIndexCoverage(w KeyValueWriter, key string, c indexCoverage) error {
+	value := append(BlockToBytes(c.first), BlockToBytes(c.last)...)
+	if err := w.Put([]byte(key), value); err != nil {
+		return fmt.Errorf("cannot put index coverage %v; %w", key, err)
+	}
+	return nil
+}
+
+// coverIndex extends the coverage recorded under key by indexed blocks between first and last.
+func (db *substateDB) coverIndex(key string, first, last uint64) error {
+	db.coverageMu.Lock()
+	defer db.coverageMu.Unlock()
+
+	coverage, err := db.getIndexCoverage(key)
+	if err != nil {
+		return err
+	}
+	merged, err := db.mergeIndexCoverage(coverage, first, last)
+	if err != nil {
+		return err
+	}
+	if merged == coverage {
+		return nil
+	}
+	return putIndexCoverage(db, key, merged)
+}
+
+// extendIndexCoverage extends c by given block of an indexed substate
+// unless c would span unindexed substates already in db.
+func (db *substateDB) extendIndexCoverage(c *indexCoverage, block uint64) error {
+	if c.covers(block) {
+		return nil
+	}
+
+	// blocks added to the coverage, substates of the current batch are not visible yet
+	first, last := block, block
+	switch {
+	case !c.valid:
+	case block > c.last:
+		first = c.last + 1
+	default:
+		last = c.first - 1
+	}
+	found, err := db.hasSubstates(first, last)
+	if err != nil || found {
+		return err
+	}
+
+	switch {
+	case !c.valid:
+		*c = indexCoverage{first: block, last: block, valid: true}
+	case block > c.last:
+		c.last = block
+	default:
+		c.first = block
+	}
+	return nil
+}
+
+// mergeIndexCoverage returns c extended by indexed blocks between first and last.
+// Ranges separated by blocks with substates cannot be merged, the longer one is returned.
+func (db *substateDB) mergeIndexCoverage(c indexCoverage, first, last uint64) (indexCoverage, error) {
+	indexed := indexCoverage{first: first, last: last, valid: true}
+	if first > last {
+		return c, nil
+	}
+	if !c.valid {
+		return indexed, nil
+	}
+
+	var found bool
+	var err error
+	switch {
+	case last+1 < c.first:
+		found, err = db.hasSubstates(last+1, c.first-1)
+	case c.last+1 < first:
+		found, err = db.hasSubstates(c.last+1, first-1)
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if !found {
+		return indexCoverage{first: min(c.first, first), last: max(c.last, last), valid: true}, nil
+	}
+	if last-first > c.last-c.first {
+		return indexed, nil
+	}
+	return c, nil
+}
+
+// hasSubstates returns true if db contains a substate between first and last block.
+func (db *substateDB) hasSubstates(first, last uint64) (bool, error) {
+	iter := db.backend.NewIterator(&util.Range{Start: SubstateDBBlockPrefix(first), Limit: substateDBBlockLimit(last)}, db.ro)
+	defer iter.Release()
+
+	found := iter.First()
+	if err := iter.Error(); err != nil {
+		return false, fmt.Errorf("cannot iterate substates between block %v and %v; %w", first, last, err)
+	}
+	return found, nil
+}
+
+// indexSegment is a block range which is either covered by an index or must be scanned.
+type indexSegment struct {
+	first, last uint64
+	useIndex    bool
+}
+
+// indexSegments splits blocks between first and last into ascending segments
+// inside and outside the coverage recorded under key.
+func (db *substateDB) indexSegments(key string, first, last uint64) ([]indexSegment, error) {
+	c, err := db.getIndexCoverage(key)
+	if err != nil {
+		return nil, err
+	}
+	if !c.valid || c.last < first || last < c.first {
+		return []indexSegment{{first: first, last: last}}, nil
+	}
+
+	var segments []indexSegment
+	if first < c.first {
+		segments = append(segments, indexSegment{first: first, last: c.first - 1})
+	}
+	segments = append(segments, indexSegment{first: max(first, c.first), last: min(last, c.last), useIndex: true})
+	if c.last < last {
+		segments = append(segments, indexSegment{first: c.last + 1, last: last})
+	}
+	return segments, nil
+}
@@ -0,0 +1,348 @@
+package db
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const LogIndexDBPrefix = "1l" // LogIndexDBPrefix + address or topic (256-bit) + block (64-bit) + tx (64-bit) -> nil
+
+// LogFilter selects logs in the same way as eth_getLogs does.
+type LogFilter struct {
+	FromBlock uint64 // first searched block
+	ToBlock   uint64 // last searched block (inclusive)
+
+	// Addresses restricts logs to those emitted by any of given addresses.
+	// An empty list matches every address.
+	Addresses []types.Address
+
+	// Topics restricts topics of logs by their position.
+	// An empty list at a position matches any topic, otherwise the topic must be one of the listed.
+	// For example {{A}, {}, {B, C}} matches logs with A as first topic and B or C as third topic.
+	Topics [][]types.Hash
+}
+
+// MayMatch returns false if no log summarized by the bloom can match the filter.
+func (f LogFilter) MayMatch(bloom types.Bloom) bool {
+	if len(f.Addresses) > 0 {
+		found := false
+		for _, addr := range f.Addresses {
+			if bloom.Test(addr.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range topics {
+			if bloom.Test(topic.Bytes()) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns true if the log matches the filter. Block range is not checked.
+func (f LogFilter) Matches(log *types.Log) bool {
+	if len(f.Addresses) > 0 {
+		found := false
+		for _, addr := range f.Addresses {
+			if log.Address == addr {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, topics := range f.Topics {
+		if len(topics) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range topics {
+			if log.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// GetLogs returns logs matching the filter ordered by block, tx and log position.
+// Returned logs have BlockNumber, TxIndex and Index (position of the log within the block) set.
+// Bloom of every transaction is checked before its logs are inspected, so non-matching transactions
+// are skipped without loading their code. The LogIndex narrows the search within its recorded coverage.
+func (db *substateDB) GetLogs(filter LogFilter) ([]*types.Log, error) {
+	if filter.FromBlock > filter.ToBlock {
+		return nil, fmt.Errorf("invalid block range %v-%v", filter.FromBlock, filter.ToBlock)
+	}
+
+	keys := logIndexKeys(filter)
+	if keys == nil {
+		// filter has no criteria, index cannot narrow the search
+		return db.filterLogs(filter, SubstateDBBlockPrefix(filter.FromBlock), substateDBBlockLimit(filter.ToBlock))
+	}
+
+	segments, err := db.indexSegments(LogIndexCoverageKey, filter.FromBlock, filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []*types.Log
+	for _, segment := range segments {
+		segmentLogs, err := db.getSegmentLogs(filter, keys, segment)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, segmentLogs...)
+	}
+	return logs, nil
+}
+
+// getSegmentLogs returns logs matching the filter within segment. Covered segments are narrowed
+// to blocks indexed under any of given keys, other segments are scanned.
+func (db *substateDB) getSegmentLogs(filter LogFilter, keys []types.Hash, segment indexSegment) ([]*types.Log, error) {
+	if !segment.useIndex {
+		return db.filterLogs(filter, SubstateDBBlockPrefix(segment.first), substateDBBlockLimit(segment.last))
+	}
+
+	blocks, err := db.getIndexedLogBlocks(keys, segment.first, segment.last)
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []*types.Log
+	for _, block := range blocks {
+		blockLogs, err := db.filterLogs(filter, SubstateDBBlockPrefix(block), substateDBBlockLimit(block))
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, blockLogs...)
+	}
+	return logs, nil
+}
+
+// BuildLogIndex indexes logs of all transactions between first and last block (including first and last)
+// and extends the recorded coverage of the LogIndex by the block range.
+func (db *substateDB) BuildLogIndex(first, last uint64, workers int) error {
+	pool := &SubstateTaskPool{
+		Name: "build-log-index",
+		TaskFunc: func(_ uint64, _ int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			return putLogs(db, ss)
+		},
+
+		First: first,
+		Last:  last,
+
+		Workers: workers,
+		DB:      db,
+	}
+
+	if err := pool.Execute(); err != nil {
+		return err
+	}
+	return db.coverIndex(LogIndexCoverageKey, first, last)
+}
+
+// filterLogs scans substates with keys between start and limit and returns logs matching the filter.
+func (db *substateDB) filterLogs(filter LogFilter, start, limit []byte) ([]*types.Log, error) {
+	iter := db.backend.NewIterator(&util.Range{Start: start, Limit: limit}, db.ro)
+	defer iter.Release()
+
+	var (
+		logs         []*types.Log
+		currentBlock uint64
+		logIndex     uint
+	)
+	for iter.Next() {
+		block, tx, err := DecodeSubstateDBKey(iter.Key())
+		if err != nil {
+			return nil, fmt.Errorf("invalid substate key: %v; %w", iter.Key(), err)
+		}
+		if block != currentBlock {
+			currentBlock = block
+			logIndex = 0
+		}
+
+		rlpSubstate, err := db.decode(iter.Value(), block)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode data into rlp block: %v, tx %v; %w", block, tx, err)
+		}
+
+		result := rlpSubstate.Result
+		if !filter.MayMatch(result.Bloom) {
+			logIndex += uint(len(result.Logs))
+			continue
+		}
+
+		for _, log := range result.Logs {
+			if filter.Matches(log) {
+				log.BlockNumber = block
+				log.TxIndex = uint(tx)
+				log.Index = logIndex
+				logs = append(logs, log)
+			}
+			logIndex++
+		}
+	}
+
+	return logs, iter.Error()
+}
+
+// getIndexedLogBlocks returns sorted numbers of blocks between first and last block
+// which contain transactions indexed under any of given keys.
+func (db *substateDB) getIndexedLogBlocks(keys []types.Hash, first, last uint64) ([]uint64, error) {
+	isCandidate := make(map[uint64]struct{})
+	for _, key := range keys {
+		r := &util.Range{
+			Start: LogIndexDBBlockPrefix(key, first),
+			Limit: LogIndexDBBlockPrefix(key, last+1),
+		}
+		if last == ^uint64(0) {
+			r.Limit = util.BytesPrefix(r.Start[:len(r.Start)-8]).Limit
+		}
+
+		iter := db.backend.NewIterator(r, db.ro)
+		for iter.Next() {
+			_, block, _, err := DecodeLogIndexDBKey(iter.Key())
+			if err != nil {
+				err = fmt.Errorf("invalid log index key: %v; %w", iter.Key(), err)
+				iter.Release()
+				return nil, err
+			}
+			isCandidate[block] = struct{}{}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	blocks := make([]uint64, 0, len(isCandidate))
+	for block := range isCandidate {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i] < blocks[j] })
+	return blocks, nil
+}
+
+// logIndexKeys returns index keys whose union contains every log matching the filter.
+// Addresses are preferred, otherwise the first restricted topic position is used.
+// Nil is returned if the filter has no criteria.
+func logIndexKeys(filter LogFilter) []types.Hash {
+	if len(filter.Addresses) > 0 {
+		keys := make([]types.Hash, len(filter.Addresses))
+		for i, addr := range filter.Addresses {
+			keys[i] = types.BytesToHash(addr.Bytes())
+		}
+		return keys
+	}
+	for _, topics := range filter.Topics {
+		if len(topics) > 0 {
+			return topics
+		}
+	}
+	return nil
+}
+
+func putLogs(w KeyValueWriter, ss *substate.Substate) error {
+	for key := range logKeys(ss) {
+		if err := w.Put(LogIndexDBKey(key, ss.Block, ss.Transaction), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteLogs(w KeyValueWriter, ss *substate.Substate) error {
+	for key := range logKeys(ss) {
+		if err := w.Delete(LogIndexDBKey(key, ss.Block, ss.Transaction)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logKeys returns addresses and topics of all logs of given substate.
+func logKeys(ss *substate.Substate) map[types.Hash]struct{} {
+	keys := make(map[types.Hash]struct{})
+	if ss.Result == nil {
+		return keys
+	}
+	for _, log := range ss.Result.Logs {
+		keys[types.BytesToHash(log.Address.Bytes())] = struct{}{}
+		for _, topic := range log.Topics {
+			keys[topic] = struct{}{}
+		}
+	}
+	return keys
+}
+
+// substateDBBlockLimit returns the first substate key after all substates of given block.
+func substateDBBlockLimit(block uint64) []byte {
+	if block == ^uint64(0) {
+		return util.BytesPrefix([]byte(SubstateDBPrefix)).Limit
+	}
+	return SubstateDBBlockPrefix(block + 1)
+}
+
+// LogIndexDBKey returns LogIndexDBPrefix with appended address or topic, block and tx number
+// creating key used in baseDB for the LogIndex. Addresses are left-padded to 32 bytes.
+func LogIndexDBKey(addressOrTopic types.Hash, block uint64, tx int) []byte {
+	key := LogIndexDBBlockPrefix(addressOrTopic, block)
+	return append(key, BlockToBytes(uint64(tx))...)
+}
+
+// LogIndexDBBlockPrefix returns LogIndexDBPrefix with appended
+// address or topic and block number creating prefix used in baseDB for the LogIndex.
+func LogIndexDBBlockPrefix(addressOrTopic types.Hash, block uint64) []byte {
+	prefix := append([]byte(LogIndexDBPrefix), addressOrTopic[:]...)
+	return append(prefix, BlockToBytes(block)...)
+}
+
+// DecodeLogIndexDBKey decodes key created by LogIndexDBKey back to address or topic, block and tx number.
+func DecodeLogIndexDBKey(key []byte) (addressOrTopic types.Hash, block uint64, tx int, err error) {
+	prefix := LogIndexDBPrefix
+	if len(key) != len(prefix)+32+8+8 {
+		err = fmt.Errorf("invalid length of log index key: %v", len(key))
+		return
+	}
+	if p := string(key[:len(prefix)]); p != prefix {
+		err = fmt.Errorf("invalid prefix of log index key: %#x", p)
+		return
+	}
+	addressOrTopic = types.BytesToHash(key[len(prefix) : len(prefix)+32])
+	blockTx := key[len(prefix)+32:]
+	block = binary.BigEndian.Uint64(blockTx[0:8])
+	tx = int(binary.BigEndian.Uint64(blockTx[8:16]))
+	return
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+var (
+	transferTopic = types.Hash{0x01}
+	approvalTopic = types.Hash{0x02}
+	tokenA        = types.Address{0xaa}
+	tokenB        = types.Address{0xbb}
+)
+
+func TestLogFilter_Matches(t *testing.T) {
+	log := &types.Log{Address: tokenA, Topics: []types.Hash{transferTopic, {0x10}}}
+
+	tests := []struct {
+		name   string
+		filter LogFilter
+		want   bool
+	}{
+		{"empty", LogFilter{}, true},
+		{"address", LogFilter{Addresses: []types.Address{tokenB, tokenA}}, true},
+		{"other address", LogFilter{Addresses: []types.Address{tokenB}}, false},
+		{"first topic", LogFilter{Topics: [][]types.Hash{{approvalTopic, transferTopic}}}, true},
+		{"wildcard topic", LogFilter{Topics: [][]types.Hash{{}, {{0x10}}}}, true},
+		{"other topic", LogFilter{Topics: [][]types.Hash{{approvalTopic}}}, false},
+		{"too many topics", LogFilter{Topics: [][]types.Hash{{}, {}, {}}}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.filter.Matches(log); got != test.want {
+				t.Fatalf("unexpected match, got: %v, want: %v", got, test.want)
+			}
+			if test.want && !test.filter.MayMatch(types.LogsBloom([]*types.Log{log})) {
+				t.Fatal("matching log must pass the bloom check")
+			}
+		})
+	}
+}
+
+func TestSubstateDB_GetLogs(t *testing.T) {
+	indexed, err := newSubstateDB(t.TempDir()+"indexed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed.EnableIndexes(LogIndex)
+
+	scanned, err := newSubstateDB(t.TempDir()+"scanned-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, db := range []*substateDB{indexed, scanned} {
+		putTestSubstates(t, db,
+			newTestSubstateWithLogs(1, 0, &types.Log{Address: tokenA, Topics: []types.Hash{transferTopic}}),
+			newTestSubstateWithLogs(1, 1,
+				&types.Log{Address: tokenB, Topics: []types.Hash{transferTopic}},
+				&types.Log{Address: tokenA, Topics: []types.Hash{approvalTopic}},
+			),
+			newTestSubstateWithLogs(2, 0, &types.Log{Address: tokenA, Topics: []types.Hash{transferTopic}}),
+			newTestSubstateWithLogs(3, 0, &types.Log{Address: tokenA, Topics: []types.Hash{transferTopic}}),
+		)
+	}
+
+	filter := LogFilter{
+		FromBlock: 1,
+		ToBlock:   2,
+		Addresses: []types.Address{tokenA},
+		Topics:    [][]types.Hash{{transferTopic, approvalTopic}},
+	}
+
+	for name, db := range map[string]*substateDB{"indexed": indexed, "scanned": scanned} {
+		t.Run(name, func(t *testing.T) {
+			logs, err := db.GetLogs(filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// block, tx, index within block
+			want := [][3]uint64{{1, 0, 0}, {1, 1, 2}, {2, 0, 0}}
+			if len(logs) != len(want) {
+				t.Fatalf("unexpected number of logs, got: %v, want: %v", len(logs), len(want))
+			}
+			for i, log := range logs {
+				got := [3]uint64{log.BlockNumber, uint64(log.TxIndex), uint64(log.Index)}
+				if got != want[i] {
+					t.Fatalf("unexpected log position\ngot: %v\nwant: %v", got, want[i])
+				}
+				if log.Address != tokenA {
+					t.Fatalf("unexpected log address %v", log.Address)
+				}
+			}
+		})
+	}
+}
+
+func newTestSubstateWithLogs(block uint64, tx int, logs ...*types.Log) *substate.Substate {
+	ss := newTestSubstate(block, tx)
+	ss.Result.Logs = logs
+	ss.Result.Bloom = types.LogsBloom(logs)
+	return ss
+}
+
+func TestSubstateDB_GetLogsOutsideIndexCoverage(t *testing.T) {
+	path := t.TempDir() + "test-db"
+	db, err := newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := func(block uint64) *substate.Substate {
+		return newTestSubstateWithLogs(block, 0, &types.Log{Address: tokenA, Topics: []types.Hash{transferTopic}})
+	}
+
+	// block 1 is recorded before the index is enabled
+	putTestSubstates(t, db, transfer(1))
+	db.EnableIndexes(LogIndex)
+	putTestSubstates(t, db, transfer(2), transfer(3))
+
+	filter := LogFilter{FromBlock: 0, ToBlock: 10, Addresses: []types.Address{tokenA}}
+	if got := logBlocks(t, db, filter); len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Fatalf("unexpected blocks\ngot: %v\nwant: [1 2 3]", got)
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err = newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// the index is used without being enabled, block 3 is missing from it
+	if err = db.Delete(LogIndexDBKey(types.BytesToHash(tokenA.Bytes()), 3, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got := logBlocks(t, db, filter); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("unexpected blocks\ngot: %v\nwant: [1 2]", got)
+	}
+
+	if err = db.BuildLogIndex(1, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	coverage, err := db.getIndexCoverage(LogIndexCoverageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (indexCoverage{first: 1, last: 3, valid: true}); coverage != want {
+		t.Fatalf("unexpected coverage\ngot: %+v\nwant: %+v", coverage, want)
+	}
+}
+
+// logBlocks returns blocks of all logs matching the filter.
+func logBlocks(t *testing.T, db SubstateDB, filter LogFilter) []uint64 {
+	logs, err := db.GetLogs(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := make([]uint64, len(logs))
+	for i, log := range logs {
+		blocks[i] = log.BlockNumber
+	}
+	return blocks
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/substate"
+)
+
+var (
+	ChainIDFlag = cli.Uint64Flag{
+		Name:  "chain-id",
+		Usage: "Chain ID of typed transactions recorded without it, 0 keeps it unset",
+	}
+
+	MigrateMessagesCommand = cli.Command{
+		Name:   "migrate-messages",
+		Usage:  "Sets missing transaction type and chain ID of all substates between first and last block",
+		Action: migrateMessagesAction,
+		Flags:  []cli.Flag{&SubstateDbFlag, &FirstBlockFlag, &LastBlockFlag, &WorkersFlag, &ChainIDFlag},
+	}
+)
+
+// MigrateMessages sets Message.Type and Message.ChainID of substates between first and last block
+// recorded without them. Type is inferred by Message.InferType, chainID is used as ChainID of typed
+// transactions. Legacy transactions keep no ChainID as substates do not record whether they are
+// EIP-155 protected. Substates with all applicable fields recorded are not rewritten.
+func (db *substateDB) MigrateMessages(first, last uint64, workers int, chainID *big.Int) error {
+	pool := &SubstateTaskPool{
+		Name: "migrate-messages",
+		TaskFunc: func(_ uint64, _ int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			msg := ss.Message
+			txType := msg.InferType()
+			setChainID := msg.ChainID == nil && chainID != nil && txType != substate.LegacyTxType
+			if msg.Type != nil && !setChainID {
+				return nil
+			}
+
+			msg.Type = &txType
+			if setChainID {
+				msg.ChainID = new(big.Int).Set(chainID)
+			}
+			return db.PutSubstate(ss)
+		},
+
+		First: first,
+		Last:  last,
+
+		Workers: workers,
+		DB:      db,
+	}
+
+	return pool.Execute()
+}
+
+func migrateMessagesAction(ctx *cli.Context) error {
+	first, last := ctx.Uint64(FirstBlockFlag.Name), ctx.Uint64(LastBlockFlag.Name)
+	if first > last {
+		return fmt.Errorf("invalid block range %v-%v", first, last)
+	}
+
+	var chainID *big.Int
+	if id := ctx.Uint64(ChainIDFlag.Name); id != 0 {
+		chainID = new(big.Int).SetUint64(id)
+	}
+
+	db, err := NewDefaultSubstateDB(ctx.Path(SubstateDbFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.MigrateMessages(first, last, ctx.Int(WorkersFlag.Name), chainID)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/substate"
+)
+
+func TestSubstateDB_MigrateMessages(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dynamicFee := newTestSubstate(1, 0)
+	dynamicFee.Message.GasFeeCap = big.NewInt(2)
+	recorded := newTestSubstate(2, 0)
+	blobType := substate.BlobTxType
+	recorded.Message.Type = &blobType
+	recorded.Message.ChainID = big.NewInt(1)
+	legacy := newTestSubstate(3, 0)
+	putTestSubstates(t, db, dynamicFee, recorded, legacy)
+
+	if err = db.MigrateMessages(0, 3, 1, big.NewInt(250)); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := db.GetSubstate(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ss.Message.Type == nil || *ss.Message.Type != substate.DynamicFeeTxType || ss.Message.ChainID.Int64() != 250 {
+		t.Fatalf("unexpected migrated message\n%v", ss.Message)
+	}
+
+	ss, err = db.GetSubstate(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *ss.Message.Type != substate.BlobTxType || ss.Message.ChainID.Int64() != 1 {
+		t.Fatalf("recorded fields must not be changed\n%v", ss.Message)
+	}
+
+	// type of legacy transactions is zero and must survive the encoding,
+	// chain ID is not set as the transaction may predate EIP-155
+	ss, err = db.GetSubstate(3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ss.Message.Type == nil || *ss.Message.Type != substate.LegacyTxType || ss.Message.ChainID != nil {
+		t.Fatalf("unexpected migrated message\n%v", ss.Message)
+	}
+}
+
+func TestMigrateMessagesCommand(t *testing.T) {
+	path := t.TempDir() + "test-db"
+	db, err := newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dynamicFee := newTestSubstate(1, 0)
+	dynamicFee.Message.GasFeeCap = big.NewInt(2)
+	putTestSubstates(t, db, dynamicFee, newTestSubstate(2, 0))
+	db.Close()
+
+	app := &cli.App{Commands: []*cli.Command{&MigrateMessagesCommand}}
+	if err = app.Run([]string{"substate", "migrate-messages", "--substate-db", path, "--last", "2", "--workers", "1", "--chain-id", "250"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = app.Run([]string{"substate", "migrate-messages", "--substate-db", path, "--first", "2", "--last", "1"}); err == nil {
+		t.Fatal("command must fail for invalid block range")
+	}
+
+	db, err = newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ss, err := db.GetSubstate(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ss.Message.Type == nil || *ss.Message.Type != substate.DynamicFeeTxType || ss.Message.ChainID.Int64() != 250 {
+		t.Fatalf("unexpected migrated message\n%v", ss.Message)
+	}
+
+	ss, err = db.GetSubstate(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ss.Message.Type == nil || *ss.Message.Type != substate.LegacyTxType || ss.Message.ChainID != nil {
+		t.Fatalf("unexpected migrated message\n%v", ss.Message)
+	}
+}
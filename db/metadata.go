@@ -9,6 +9,15 @@ const (
 	UpdatesetPrefix      = "us"
 	UpdatesetIntervalKey = MetadataPrefix + UpdatesetPrefix + "in"
 	UpdatesetSizeKey     = MetadataPrefix + UpdatesetPrefix + "si"
+
+	// AddressIndexCoverageKey maps to the first and last block (64-bit each) of the block range
+	// in which all substates are inserted into the AddressIndex.
+	AddressIndexCoverageKey = MetadataPrefix + AddressIndexDBPrefix + "co"
+	// LogIndexCoverageKey maps to the block range in which all substates are inserted into the LogIndex.
+	LogIndexCoverageKey = MetadataPrefix + LogIndexDBPrefix + "co"
+	// BlockHashTableKey marks DBs with a block-hash table, its value is empty.
+	// Substates without inline BlockHashes are resolved from the table only if the marker is present.
+	BlockHashTableKey = MetadataPrefix + BlockHashDBPrefix + "ta"
 )
 
 // PutMetadata into db
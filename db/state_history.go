@@ -0,0 +1,140 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// AccountChange is the state of an account after the given transaction.
+type AccountChange struct {
+	Block       uint64
+	Transaction int
+	Nonce       uint64
+	Balance     *big.Int
+	CodeHash    types.Hash
+	Destroyed   bool // account was destroyed by the transaction
+}
+
+// StorageChange is the value of a storage slot after the given transaction.
+type StorageChange struct {
+	Block       uint64
+	Transaction int
+	Value       types.Hash
+}
+
+// GetAccountHistory returns states of addr between first and last block (including first and last).
+// A state is returned for the first transaction touching addr and for every transaction changing
+// nonce, balance or code of addr. If ddb is not nil, destructions of addr are returned as empty states.
+// The AddressIndex is used within its recorded coverage.
+func (db *substateDB) GetAccountHistory(ddb *DestroyedAccountDB, addr types.Address, first, last uint64) ([]AccountChange, error) {
+	var history []AccountChange
+	err := db.walkAccountHistory(ddb, addr, first, last, func(ss *substate.Substate, acc *substate.Account, destroyed, _ bool) {
+		change := AccountChange{
+			Block:       ss.Block,
+			Transaction: ss.Transaction,
+			Nonce:       acc.Nonce,
+			Balance:     balanceOf(acc),
+			CodeHash:    acc.CodeHash(),
+			Destroyed:   destroyed,
+		}
+
+		if n := len(history); n > 0 {
+			prev := history[n-1]
+			if prev.Nonce == change.Nonce && prev.Balance.Cmp(change.Balance) == 0 &&
+				prev.CodeHash == change.CodeHash && prev.Destroyed == change.Destroyed {
+				return
+			}
+		}
+		history = append(history, change)
+	})
+	return history, err
+}
+
+// GetStorageHistory returns values of slot of addr between first and last block (including first and last).
+// A value is returned for the first transaction reading or writing the slot and for every transaction
+// changing it. If ddb is not nil, destructions of addr are returned as zero values.
+// The AddressIndex is used within its recorded coverage.
+func (db *substateDB) GetStorageHistory(ddb *DestroyedAccountDB, addr types.Address, slot types.Hash, first, last uint64) ([]StorageChange, error) {
+	var history []StorageChange
+	err := db.walkAccountHistory(ddb, addr, first, last, func(ss *substate.Substate, acc *substate.Account, _, cleared bool) {
+		value, found := acc.Storage[slot]
+		if !found && !cleared {
+			// slot is not written, its value is unchanged if it was read
+			if in, ok := ss.InputSubstate[addr]; ok {
+				value, found = in.Storage[slot]
+			}
+			if !found {
+				return
+			}
+		}
+
+		if n := len(history); n > 0 && history[n-1].Value == value {
+			return
+		}
+		history = append(history, StorageChange{Block: ss.Block, Transaction: ss.Transaction, Value: value})
+	})
+	return history, err
+}
+
+// walkAccountHistory calls visit with state of addr after every transaction touching addr.
+// Accounts which were destroyed and not resurrected by the transaction are visited as empty accounts.
+// Cleared is true if storage of the account was cleared by the transaction, i.e. it was destroyed or resurrected.
+func (db *substateDB) walkAccountHistory(ddb *DestroyedAccountDB, addr types.Address, first, last uint64, visit func(ss *substate.Substate, acc *substate.Account, destroyed, cleared bool)) error {
+	iter := db.NewAddressSubstateIterator(addr, first, last)
+	defer iter.Release()
+
+	for iter.Next() {
+		ss := iter.Value()
+
+		destroyed, resurrected, err := isDestroyed(ddb, addr, ss.Block, ss.Transaction)
+		if err != nil {
+			return err
+		}
+
+		acc, found := ss.OutputSubstate[addr]
+		if !found {
+			acc, found = ss.InputSubstate[addr]
+		}
+		switch {
+		case destroyed:
+			acc = substate.NewAccount(0, new(big.Int), nil)
+		case !found:
+			// addr is only the sender or the recipient of the message
+			continue
+		}
+
+		visit(ss, acc, destroyed, destroyed || resurrected)
+	}
+
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate substates of %s; %w", addr, err)
+	}
+	return nil
+}
+
+// isDestroyed returns whether addr was destroyed or resurrected by given transaction.
+func isDestroyed(ddb *DestroyedAccountDB, addr types.Address, block uint64, tx int) (destroyed bool, resurrected bool, err error) {
+	if ddb == nil {
+		return false, false, nil
+	}
+
+	des, res, err := ddb.GetDestroyedAccounts(block, tx)
+	if err != nil {
+		return false, false, fmt.Errorf("cannot get destroyed accounts block: %v, tx: %v; %w", block, tx, err)
+	}
+
+	for _, a := range res {
+		if a == addr {
+			return false, true, nil
+		}
+	}
+	for _, a := range des {
+		if a == addr {
+			return true, false, nil
+		}
+	}
+	return false, false, nil
+}
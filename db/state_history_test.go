@@ -0,0 +1,87 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+func TestSubstateDB_GetAccountHistory_AndGetStorageHistory(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := types.Address{5}
+	slot := types.Hash{1}
+	newContract := func(balance int64, code []byte, value byte) *substate.Account {
+		acc := substate.NewAccount(1, big.NewInt(balance), code)
+		acc.Storage[slot] = types.Hash{value}
+		return acc
+	}
+
+	created := newTestSubstate(1, 0)
+	created.OutputSubstate[contract] = newContract(0, []byte{1}, 1)
+
+	written := newTestSubstate(2, 0)
+	written.InputSubstate[contract] = newContract(0, []byte{1}, 1)
+	written.OutputSubstate[contract] = newContract(5, []byte{1}, 2)
+
+	// reads the contract without changing it
+	read := newTestSubstate(2, 1)
+	read.InputSubstate[contract] = newContract(5, []byte{1}, 2)
+
+	destroyed := newTestSubstate(3, 0)
+	destroyed.InputSubstate[contract] = newContract(5, []byte{1}, 2)
+	if err = ddb.SetDestroyedAccounts(3, 0, []types.Address{contract}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	recreated := newTestSubstate(4, 0)
+	recreated.OutputSubstate[contract] = substate.NewAccount(1, big.NewInt(0), []byte{2})
+
+	putTestSubstates(t, db, created, written, read, destroyed, recreated)
+
+	accounts, err := db.GetAccountHistory(ddb, contract, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantAccounts := []AccountChange{
+		{Block: 1, Nonce: 1, Balance: big.NewInt(0), CodeHash: hash.Keccak256Hash([]byte{1})},
+		{Block: 2, Nonce: 1, Balance: big.NewInt(5), CodeHash: hash.Keccak256Hash([]byte{1})},
+		{Block: 3, Nonce: 0, Balance: big.NewInt(0), CodeHash: hash.Keccak256Hash(nil), Destroyed: true},
+		{Block: 4, Nonce: 1, Balance: big.NewInt(0), CodeHash: hash.Keccak256Hash([]byte{2})},
+	}
+	if len(accounts) != len(wantAccounts) {
+		t.Fatalf("unexpected account history\ngot: %v\nwant: %v", accounts, wantAccounts)
+	}
+	for i, want := range wantAccounts {
+		got := accounts[i]
+		if got.Block != want.Block || got.Transaction != want.Transaction || got.Nonce != want.Nonce ||
+			got.Balance.Cmp(want.Balance) != 0 || got.CodeHash != want.CodeHash || got.Destroyed != want.Destroyed {
+			t.Fatalf("unexpected account change %v\ngot: %v\nwant: %v", i, got, want)
+		}
+	}
+
+	values, err := db.GetStorageHistory(ddb, contract, slot, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantValues := []StorageChange{
+		{Block: 1, Value: types.Hash{1}},
+		{Block: 2, Value: types.Hash{2}},
+		{Block: 3, Value: types.Hash{}},
+	}
+	if len(values) != len(wantValues) || values[0] != wantValues[0] || values[1] != wantValues[1] || values[2] != wantValues[2] {
+		t.Fatalf("unexpected storage history\ngot: %v\nwant: %v", values, wantValues)
+	}
+}
@@ -0,0 +1,120 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/trie"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// StateReconstructor reconstructs the world state at any block/tx position.
+// UpdateSets are folded up to the closest UpdateSet preceding the position
+// and Substates of remaining transactions are replayed on top of them.
+type StateReconstructor struct {
+	UpdateDB   UpdateDB
+	SubstateDB SubstateDB
+
+	// DestroyedAccountDB is optional. If set, destroyed and resurrected accounts
+	// are deleted before the OutputSubstate of replayed transaction is merged.
+	DestroyedAccountDB *DestroyedAccountDB
+
+	// Workers is the number of workers decoding Substates, at least one worker is used.
+	Workers int
+}
+
+// StateAt returns the world state after all transactions of given block.
+func (r *StateReconstructor) StateAt(block uint64) (substate.WorldState, error) {
+	return r.StateBefore(block+1, 0)
+}
+
+// StateBefore returns the world state before transaction tx of given block.
+// Note: Only accounts recorded by UpdateSets and Substates are present in the state.
+func (r *StateReconstructor) StateBefore(block uint64, tx int) (substate.WorldState, error) {
+	ws := substate.NewWorldState()
+
+	var next uint64
+	if block > 0 {
+		last, err := r.foldUpdateSets(ws, block-1)
+		if err != nil {
+			return nil, err
+		}
+		next = last
+	}
+
+	if err := r.replaySubstates(ws, next, block, tx); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// GetProof returns proof of account addr and its storage slots keys within the world state
+// after all transactions of given block. The proof is verifiable against the returned state root.
+func (r *StateReconstructor) GetProof(block uint64, addr types.Address, keys []types.Hash) (*trie.AccountResult, types.Hash, error) {
+	ws, err := r.StateAt(block)
+	if err != nil {
+		return nil, types.Hash{}, err
+	}
+
+	p := trie.NewStateProver(ws)
+	return p.GetProof(addr, keys), p.Root(), nil
+}
+
+// foldUpdateSets applies all UpdateSets until given block (including) to ws.
+// It returns block following the last applied UpdateSet.
+func (r *StateReconstructor) foldUpdateSets(ws substate.WorldState, block uint64) (uint64, error) {
+	iter := r.UpdateDB.NewUpdateSetIterator(0, block)
+	defer iter.Release()
+
+	var next uint64
+	for iter.Next() {
+		us := iter.Value()
+		for _, addr := range us.DeletedAccounts {
+			delete(ws, addr)
+		}
+		ws.Merge(us.WorldState)
+		next = us.Block + 1
+	}
+
+	if err := iter.Error(); err != nil {
+		return 0, fmt.Errorf("cannot iterate update-sets; %w", err)
+	}
+	return next, nil
+}
+
+// replaySubstates merges OutputSubstates of transactions from first block
+// until given block and tx (excluding) into ws.
+// Deletions are applied per transaction rather than by GetAccountsDestroyedInRange,
+// otherwise accounts written before their destruction within the range would be kept.
+func (r *StateReconstructor) replaySubstates(ws substate.WorldState, first, block uint64, tx int) error {
+	if first > block || (first == block && tx == 0) {
+		return nil
+	}
+
+	iter := r.SubstateDB.NewSubstateIterator(int(first), max(r.Workers, 1))
+	defer iter.Release()
+
+	for iter.Next() {
+		ss := iter.Value()
+		if ss.Block > block || (ss.Block == block && ss.Transaction >= tx) {
+			break
+		}
+
+		if r.DestroyedAccountDB != nil {
+			destroyed, resurrected, err := r.DestroyedAccountDB.GetDestroyedAccounts(ss.Block, ss.Transaction)
+			if err != nil {
+				return fmt.Errorf("cannot get destroyed accounts block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+			}
+			for _, addr := range append(destroyed, resurrected...) {
+				delete(ws, addr)
+			}
+		}
+
+		ws.Merge(ss.OutputSubstate)
+	}
+
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate substates; %w", err)
+	}
+	return nil
+}
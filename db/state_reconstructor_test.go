@@ -0,0 +1,128 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/trie"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestStateReconstructor_StateAt(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var substates []*substate.Substate
+	for block := uint64(1); block <= 7; block++ {
+		ss := newTestSubstate(block, 0)
+		ss.OutputSubstate[types.Address{1}].Balance = big.NewInt(int64(block))
+		substates = append(substates, ss)
+	}
+	// account 2 is destroyed in block 4
+	delete(substates[3].OutputSubstate, types.Address{2})
+	if err = ddb.SetDestroyedAccounts(4, 0, []types.Address{{2}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, sdb, substates...)
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, DestroyedAccountDB: ddb, Interval: 3}
+	if err = g.Generate(0, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &StateReconstructor{UpdateDB: udb, SubstateDB: sdb, DestroyedAccountDB: ddb}
+	for block := uint64(1); block <= 7; block++ {
+		ws, err := r.StateAt(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := ws[types.Address{1}].Balance.Uint64(); got != block {
+			t.Fatalf("unexpected balance at block %v\ngot: %v\nwant: %v", block, got, block)
+		}
+		if _, found := ws[types.Address{2}]; found != (block != 4) {
+			t.Fatalf("unexpected presence of destroyed account at block %v", block)
+		}
+	}
+}
+
+func TestStateReconstructor_StateBefore(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := newTestSubstate(2, 0)
+	second := newTestSubstate(2, 1)
+	second.OutputSubstate[types.Address{1}].Nonce = 3
+	putTestSubstates(t, sdb, first, second)
+
+	r := &StateReconstructor{UpdateDB: udb, SubstateDB: sdb}
+
+	ws, err := r.StateBefore(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 0 {
+		t.Fatalf("state before the first transaction must be empty, got: %v", ws)
+	}
+
+	ws, err = r.StateBefore(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Equal(first.OutputSubstate) {
+		t.Fatalf("unexpected state\ngot: %v\nwant: %v", ws, first.OutputSubstate)
+	}
+
+	ws, err = r.StateAt(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ws[types.Address{1}].Nonce; got != 3 {
+		t.Fatalf("unexpected nonce\ngot: %v\nwant: 3", got)
+	}
+}
+
+func TestStateReconstructor_GetProof(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newTestSubstate(1, 0)
+	ss.OutputSubstate[types.Address{2}].Storage[types.Hash{1}] = types.Hash{2}
+	putTestSubstates(t, sdb, ss)
+
+	r := &StateReconstructor{UpdateDB: udb, SubstateDB: sdb}
+	res, root, err := r.GetProof(1, types.Address{2}, []types.Hash{{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = trie.VerifyAccountProof(root, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Balance.Uint64() != 10 || res.StorageProof[0].Value != (types.Hash{2}) {
+		t.Fatalf("unexpected proof result: %+v", res)
+	}
+}
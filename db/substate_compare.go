@@ -0,0 +1,205 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// SubstateDiffKind describes how a substate differs between two compared DBs.
+type SubstateDiffKind int
+
+const (
+	// MissingSubstate means the substate exists only in the first DB.
+	MissingSubstate SubstateDiffKind = iota
+	// ExtraSubstate means the substate exists only in the second DB.
+	ExtraSubstate
+	// DifferentSubstate means the substate exists in both DBs but its content differs.
+	DifferentSubstate
+)
+
+func (k SubstateDiffKind) String() string {
+	switch k {
+	case MissingSubstate:
+		return "missing"
+	case ExtraSubstate:
+		return "extra"
+	case DifferentSubstate:
+		return "different"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// SubstateDiff is a single difference found by CompareSubstateDBs.
+type SubstateDiff struct {
+	Block       uint64
+	Transaction int
+	Kind        SubstateDiffKind
+	// Err describes structural differences, it is only set for DifferentSubstate.
+	Err error
+}
+
+func (d SubstateDiff) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%v_%v: %v substate; %v", d.Block, d.Transaction, d.Kind, d.Err)
+	}
+	return fmt.Sprintf("%v_%v: %v substate", d.Block, d.Transaction, d.Kind)
+}
+
+// CompareOptions configures CompareSubstateDBs.
+type CompareOptions struct {
+	First uint64 // first compared block
+	Last  uint64 // last compared block (inclusive)
+
+	Workers int // number of workers decoding and comparing substates
+
+	// IgnoreBlockHashes excludes Env.BlockHashes from the comparison.
+	IgnoreBlockHashes bool
+	// IgnoreZeroStorage treats zero-valued storage slots as non-existing,
+	// so a slot which is zero on one side and missing on the other is not reported.
+	IgnoreZeroStorage bool
+}
+
+// CompareSubstateDBs compares substates of want and got block by block within the range given by opt.
+// It returns every transaction which is missing in got, extra in got or whose substate differs.
+// Returned diffs are ordered by block and transaction number.
+func CompareSubstateDBs(want, got SubstateDB, opt CompareOptions) ([]SubstateDiff, error) {
+	if opt.Workers <= 0 {
+		opt.Workers = 1
+	}
+
+	wantIter := want.NewSubstateIterator(int(opt.First), opt.Workers)
+	defer wantIter.Release()
+
+	gotIter := got.NewSubstateIterator(int(opt.First), opt.Workers)
+	defer gotIter.Release()
+
+	var (
+		diffs []SubstateDiff
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+	)
+
+	// compare substates present in both DBs in parallel
+	pairs := make(chan [2]*substate.Substate, opt.Workers*10)
+	for w := 0; w < opt.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pair := range pairs {
+				opt.normalize(pair[0])
+				opt.normalize(pair[1])
+				if err := pair[0].Equal(pair[1]); err != nil {
+					mu.Lock()
+					diffs = append(diffs, SubstateDiff{
+						Block:       pair[0].Block,
+						Transaction: pair[0].Transaction,
+						Kind:        DifferentSubstate,
+						Err:         err,
+					})
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	x := opt.next(wantIter)
+	y := opt.next(gotIter)
+	for x != nil || y != nil {
+		c := comparePositions(x, y)
+		switch {
+		case c == 0:
+			pairs <- [2]*substate.Substate{x, y}
+			x = opt.next(wantIter)
+			y = opt.next(gotIter)
+		case c < 0:
+			mu.Lock()
+			diffs = append(diffs, SubstateDiff{Block: x.Block, Transaction: x.Transaction, Kind: MissingSubstate})
+			mu.Unlock()
+			x = opt.next(wantIter)
+		default:
+			mu.Lock()
+			diffs = append(diffs, SubstateDiff{Block: y.Block, Transaction: y.Transaction, Kind: ExtraSubstate})
+			mu.Unlock()
+			y = opt.next(gotIter)
+		}
+	}
+	close(pairs)
+	wg.Wait()
+
+	if err := errors.Join(wantIter.Error(), gotIter.Error()); err != nil {
+		return nil, fmt.Errorf("cannot iterate substates; %w", err)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Block != diffs[j].Block {
+			return diffs[i].Block < diffs[j].Block
+		}
+		return diffs[i].Transaction < diffs[j].Transaction
+	})
+
+	return diffs, nil
+}
+
+// next returns next substate from iter or nil if iter is exhausted or past the last block.
+func (opt CompareOptions) next(iter Iterator[*substate.Substate]) *substate.Substate {
+	if !iter.Next() {
+		return nil
+	}
+	ss := iter.Value()
+	if ss.Block > opt.Last {
+		return nil
+	}
+	return ss
+}
+
+// normalize removes the fields ignored by opt from ss.
+func (opt CompareOptions) normalize(ss *substate.Substate) {
+	if opt.IgnoreBlockHashes && ss.Env != nil {
+		ss.Env.BlockHashes = nil
+	}
+	if opt.IgnoreZeroStorage {
+		removeZeroStorage(ss.InputSubstate)
+		removeZeroStorage(ss.OutputSubstate)
+	}
+}
+
+func removeZeroStorage(ws substate.WorldState) {
+	for _, acc := range ws {
+		for key, value := range acc.Storage {
+			if value == (types.Hash{}) {
+				delete(acc.Storage, key)
+			}
+		}
+	}
+}
+
+// comparePositions compares block and transaction number of x and y.
+// A nil substate is considered to be after any other substate.
+func comparePositions(x, y *substate.Substate) int {
+	switch {
+	case x == nil && y == nil:
+		return 0
+	case x == nil:
+		return 1
+	case y == nil:
+		return -1
+	case x.Block != y.Block:
+		if x.Block < y.Block {
+			return -1
+		}
+		return 1
+	case x.Transaction != y.Transaction:
+		if x.Transaction < y.Transaction {
+			return -1
+		}
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestCompareSubstateDBs_ReportsMissingExtraAndDifferent(t *testing.T) {
+	want, err := newSubstateDB(t.TempDir()+"want-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := newSubstateDB(t.TempDir()+"got-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 1_0 is equal, 1_1 is missing in got, 2_0 differs and 3_0 is extra in got
+	putTestSubstates(t, want, newTestSubstate(1, 0), newTestSubstate(1, 1), newTestSubstate(2, 0))
+
+	different := newTestSubstate(2, 0)
+	different.Result.GasUsed = 2
+	putTestSubstates(t, got, newTestSubstate(1, 0), different, newTestSubstate(3, 0))
+
+	diffs, err := CompareSubstateDBs(want, got, CompareOptions{First: 0, Last: 10, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SubstateDiff{
+		{Block: 1, Transaction: 1, Kind: MissingSubstate},
+		{Block: 2, Transaction: 0, Kind: DifferentSubstate},
+		{Block: 3, Transaction: 0, Kind: ExtraSubstate},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("unexpected number of diffs\ngot: %v\nwant: %v", diffs, expected)
+	}
+	for i, d := range diffs {
+		if d.Block != expected[i].Block || d.Transaction != expected[i].Transaction || d.Kind != expected[i].Kind {
+			t.Fatalf("unexpected diff %v\ngot: %v\nwant: %v", i, d, expected[i])
+		}
+	}
+	if diffs[1].Err == nil {
+		t.Fatal("different substate must carry an error")
+	}
+}
+
+func TestCompareSubstateDBs_RespectsBlockRange(t *testing.T) {
+	want, err := newSubstateDB(t.TempDir()+"want-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := newSubstateDB(t.TempDir()+"got-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, want, newTestSubstate(1, 0), newTestSubstate(5, 0))
+	putTestSubstates(t, got, newTestSubstate(2, 0), newTestSubstate(5, 0))
+
+	diffs, err := CompareSubstateDBs(want, got, CompareOptions{First: 3, Last: 4, Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("no diffs expected, got %v", diffs)
+	}
+}
+
+func TestCompareSubstateDBs_IgnoresConfiguredFields(t *testing.T) {
+	want, err := newSubstateDB(t.TempDir()+"want-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := newSubstateDB(t.TempDir()+"got-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := newTestSubstate(1, 0)
+	x.Env.BlockHashes = map[uint64]types.Hash{0: {1}}
+	x.OutputSubstate[types.Address{2}].Storage[types.Hash{1}] = types.Hash{}
+	putTestSubstates(t, want, x)
+	putTestSubstates(t, got, newTestSubstate(1, 0))
+
+	diffs, err := CompareSubstateDBs(want, got, CompareOptions{First: 0, Last: 1, Workers: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("expected exactly one diff, got %v", diffs)
+	}
+
+	diffs, err = CompareSubstateDBs(want, got, CompareOptions{First: 0, Last: 1, Workers: 1, IgnoreBlockHashes: true, IgnoreZeroStorage: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("no diffs expected, got %v", diffs)
+	}
+}
+
+// newTestSubstate returns a new substate for given block and tx which does not share any data with other substates.
+func newTestSubstate(block uint64, tx int) *substate.Substate {
+	to := types.Address{2}
+	return &substate.Substate{
+		InputSubstate: substate.WorldState{
+			types.Address{1}: substate.NewAccount(1, big.NewInt(100), nil),
+		},
+		OutputSubstate: substate.WorldState{
+			types.Address{1}: substate.NewAccount(2, big.NewInt(90), nil),
+			types.Address{2}: substate.NewAccount(0, big.NewInt(10), nil),
+		},
+		Env: &substate.Env{
+			Coinbase:   types.Address{3},
+			Difficulty: big.NewInt(1),
+			GasLimit:   1_000_000,
+			Number:     block,
+			Timestamp:  block,
+			BaseFee:    big.NewInt(1),
+		},
+		Message:     substate.NewMessage(1, true, big.NewInt(1), 21_000, types.Address{1}, &to, big.NewInt(10), nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), nil, nil),
+		Result:      substate.NewResult(1, types.Bloom{}, []*types.Log{}, types.Address{}, 21_000),
+		Block:       block,
+		Transaction: tx,
+	}
+}
+
+func putTestSubstates(t *testing.T, db SubstateDB, substates ...*substate.Substate) {
+	for _, ss := range substates {
+		if err := db.PutSubstate(ss); err != nil {
+			t.Fatalf("cannot put substate; %v", err)
+		}
+	}
+}
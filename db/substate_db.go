@@ -2,10 +2,18 @@ package db
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"sync/atomic"
 
+	"github.com/Fantom-foundation/Substate/chain"
 	"github.com/Fantom-foundation/Substate/rlp"
 	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/trie"
+	"github.com/Fantom-foundation/Substate/types"
 	trlp "github.com/Fantom-foundation/Substate/types/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/opt"
@@ -31,13 +39,87 @@ type SubstateDB interface {
 	// PutSubstate inserts given substate to DB.
 	PutSubstate(substate *substate.Substate) error
 
-	// DeleteSubstate deletes Substate for given block and tx number.
+	// PutSubstates inserts given substates to DB within a single batch.
+	PutSubstates(substates ...*substate.Substate) error
+
+	// DeleteSubstate deletes Substate for given block and tx number together with its index entries.
 	DeleteSubstate(block uint64, tx int) error
 
 	NewSubstateIterator(start int, numWorkers int) Iterator[*substate.Substate]
 
 	NewSubstateTaskPool(name string, taskFunc SubstateTaskFunc, first, last uint64, ctx *cli.Context) *SubstateTaskPool
 
+	// EnableIndexes enables maintenance of given optional indexes by PutSubstate.
+	EnableIndexes(flags IndexFlags)
+
+	// SetProfile sets the fork schedule of the recorded chain used to decode substates.
+	// Without a profile the layout of every substate is guessed.
+	SetProfile(profile *chain.Profile)
+
+	// GetSubstateByTxHash returns the Substate of transaction with given hash.
+	// The TxHashIndex must be maintained or backfilled for the transaction.
+	GetSubstateByTxHash(txHash types.Hash) (*substate.Substate, error)
+
+	// GetTxPosition returns block and tx number of transaction with given hash.
+	GetTxPosition(txHash types.Hash) (uint64, int, error)
+
+	// PutTxHash inserts given tx hash with its block and tx number into the TxHashIndex.
+	PutTxHash(txHash types.Hash, block uint64, tx int) error
+
+	// DeleteTxHash deletes given tx hash from the TxHashIndex.
+	DeleteTxHash(txHash types.Hash) error
+
+	// BackfillTxHashIndex inserts hashes of all transactions between first and last block into the TxHashIndex.
+	BackfillTxHashIndex(first, last uint64, workers int, getTxHash TxHashFunc) error
+
+	// BuildAddressIndex inserts all transactions between first and last block into the AddressIndex
+	// and extends its recorded coverage.
+	BuildAddressIndex(first, last uint64, workers int) error
+
+	// NewAddressSubstateIterator returns iterator over substates touching given address between first and last block.
+	// The AddressIndex is used within its recorded coverage, substates outside of it are scanned.
+	NewAddressSubstateIterator(addr types.Address, first, last uint64) Iterator[*substate.Substate]
+
+	// GetLogs returns all recorded logs matching given filter.
+	// The LogIndex is used within its recorded coverage, substates outside of it are scanned.
+	GetLogs(filter LogFilter) ([]*types.Log, error)
+
+	// BuildLogIndex inserts logs of all transactions between first and last block into the LogIndex
+	// and extends its recorded coverage.
+	BuildLogIndex(first, last uint64, workers int) error
+
+	// GetAccountHistory returns changes of given account between first and last block.
+	// Destructions recorded in ddb are returned as empty accounts, ddb may be nil.
+	GetAccountHistory(ddb *DestroyedAccountDB, addr types.Address, first, last uint64) ([]AccountChange, error)
+
+	// GetStorageHistory returns changes of given storage slot between first and last block.
+	// Destructions recorded in ddb are returned as zero values, ddb may be nil.
+	GetStorageHistory(ddb *DestroyedAccountDB, addr types.Address, slot types.Hash, first, last uint64) ([]StorageChange, error)
+
+	// GetCodeHashHistory returns changes of code hash of given address between first and last block.
+	// Destructions recorded in ddb are returned as the empty code hash, ddb may be nil.
+	GetCodeHashHistory(ddb *DestroyedAccountDB, addr types.Address, first, last uint64) ([]CodeHashChange, error)
+
+	// GetCodeByAddress returns code of given address after the given block.
+	// Code of an account destroyed according to ddb is empty, ddb may be nil.
+	GetCodeByAddress(ddb *DestroyedAccountDB, addr types.Address, block uint64) ([]byte, error)
+
+	// MigrateMessages sets missing Message.Type of all substates between first and last block
+	// and missing Message.ChainID of their typed transactions.
+	MigrateMessages(first, last uint64, workers int, chainID *big.Int) error
+
+	// GetReceiptsRoot returns root hash of the receipts trie rebuilt from results of all substates of given block.
+	GetReceiptsRoot(block uint64) (types.Hash, error)
+
+	// GetBlockHash returns hash of given block from the block-hash table.
+	GetBlockHash(block uint64) (types.Hash, error)
+
+	// PutBlockHash inserts hash of given block into the block-hash table.
+	PutBlockHash(block uint64, hash types.Hash) error
+
+	// BackfillBlockHashes inserts inline BlockHashes of all substates between first and last block into the block-hash table.
+	BackfillBlockHashes(first, last uint64, workers int) error
+
 	// GetFirstSubstate returns last substate (block and transaction wise) inside given DB.
 	GetFirstSubstate() *substate.Substate
 
@@ -57,11 +139,11 @@ func NewSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Re
 }
 
 func MakeDefaultSubstateDB(db *leveldb.DB) SubstateDB {
-	return &substateDB{&codeDB{&baseDB{backend: db}}}
+	return &substateDB{codeDB: &codeDB{&baseDB{backend: db}}}
 }
 
 func MakeDefaultSubstateDBFromBaseDB(db BaseDB) SubstateDB {
-	return &substateDB{&codeDB{&baseDB{backend: db.getBackend()}}}
+	return &substateDB{codeDB: &codeDB{&baseDB{backend: db.getBackend()}}}
 }
 
 // NewReadOnlySubstateDB creates a new instance of read-only SubstateDB.
@@ -70,7 +152,7 @@ func NewReadOnlySubstateDB(path string) (SubstateDB, error) {
 }
 
 func MakeSubstateDB(db *leveldb.DB, wo *opt.WriteOptions, ro *opt.ReadOptions) SubstateDB {
-	return &substateDB{&codeDB{&baseDB{backend: db, wo: wo, ro: ro}}}
+	return &substateDB{codeDB: &codeDB{&baseDB{backend: db, wo: wo, ro: ro}}}
 }
 
 func newSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.ReadOptions) (*substateDB, error) {
@@ -78,11 +160,52 @@ func newSubstateDB(path string, o *opt.Options, wo *opt.WriteOptions, ro *opt.Re
 	if err != nil {
 		return nil, err
 	}
-	return &substateDB{base}, nil
+	return &substateDB{codeDB: base}, nil
 }
 
+// IndexFlags selects optional secondary indexes maintained by PutSubstate.
+type IndexFlags uint8
+
+const (
+	// TxHashIndex maps hashes of transactions to their block and tx number.
+	// Only substates with recorded Message.TxHash are indexed by PutSubstate.
+	TxHashIndex IndexFlags = 1 << iota
+	// AddressIndex maps addresses to block and tx numbers of transactions touching them.
+	// The block range of indexed substates is recorded in the DB, readers scan substates outside of it.
+	// Substates within the range must be written with the index enabled.
+	AddressIndex
+	// LogIndex maps log addresses and topics to block and tx numbers of transactions emitting them.
+	// Its coverage is recorded in the same way as the one of the AddressIndex.
+	LogIndex
+	// BlockHashIndex stores Env.BlockHashes in the block-hash table instead of within each substate.
+	// The DB is marked to have the table, decoded substates without inline hashes then get
+	// the whole BLOCKHASH window from the table.
+	BlockHashIndex
+)
+
 type substateDB struct {
 	*codeDB
+	indexes IndexFlags
+	profile *chain.Profile
+
+	coverageMu     sync.Mutex    // guards updates of index coverages
+	blockHashTable atomic.Uint32 // cached presence of BlockHashTableKey, see hasBlockHashTable
+}
+
+func (db *substateDB) EnableIndexes(flags IndexFlags) {
+	db.indexes |= flags
+}
+
+func (db *substateDB) SetProfile(profile *chain.Profile) {
+	db.profile = profile
+}
+
+// decode decodes val of a substate recorded in given block into RLP.
+func (db *substateDB) decode(val []byte, block uint64) (*rlp.RLP, error) {
+	if db.profile == nil {
+		return rlp.Decode(val)
+	}
+	return rlp.DecodeFork(val, db.profile.ForkAt(block))
 }
 
 func (db *substateDB) GetFirstSubstate() *substate.Substate {
@@ -108,12 +231,12 @@ func (db *substateDB) GetSubstate(block uint64, tx int) (*substate.Substate, err
 		return nil, fmt.Errorf("cannot get substate block: %v, tx: %v from db; %w", block, tx, err)
 	}
 
-	rlpSubstate, err := rlp.Decode(val)
+	rlpSubstate, err := db.decode(val, block)
 	if err != nil {
 		return nil, fmt.Errorf("cannot decode data into rlp block: %v, tx %v; %w", block, tx, err)
 	}
 
-	return rlpSubstate.ToSubstate(db.GetCode, block, tx)
+	return db.toSubstate(rlpSubstate, block, tx)
 }
 
 // GetBlockSubstates returns substates for given block if exists within DB.
@@ -138,12 +261,12 @@ func (db *substateDB) GetBlockSubstates(block uint64) (map[int]*substate.Substat
 			return nil, fmt.Errorf("record-replay: GetBlockSubstates(%v) iterated substates from block %v", block, b)
 		}
 
-		rlpSubstate, err := rlp.Decode(value)
+		rlpSubstate, err := db.decode(value, block)
 		if err != nil {
 			return nil, fmt.Errorf("cannot decode data into rlp block: %v, tx %v; %w", block, tx, err)
 		}
 
-		sbstt, err := rlpSubstate.ToSubstate(db.GetCode, block, tx)
+		sbstt, err := db.toSubstate(rlpSubstate, block, tx)
 		if err != nil {
 			return nil, fmt.Errorf("cannot decode data into substate: %w", err)
 		}
@@ -160,22 +283,91 @@ func (db *substateDB) GetBlockSubstates(block uint64) (map[int]*substate.Substat
 }
 
 func (db *substateDB) PutSubstate(ss *substate.Substate) error {
+	if db.indexes&coveredIndexFlags != 0 {
+		db.coverageMu.Lock()
+		defer db.coverageMu.Unlock()
+	}
+
+	return db.putSubstates(db, ss)
+}
+
+// PutSubstates inserts given substates including their codes and index entries into DB within a single batch.
+func (db *substateDB) PutSubstates(substates ...*substate.Substate) error {
+	if db.indexes&coveredIndexFlags != 0 {
+		db.coverageMu.Lock()
+		defer db.coverageMu.Unlock()
+	}
+
+	batch := db.NewBatch()
+	if err := db.putSubstates(batch, substates...); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return fmt.Errorf("cannot write batch of %v substates; %w", len(substates), err)
+	}
+	return nil
+}
+
+// putSubstates writes given substates into w. Coverages of enabled coveredIndexes
+// are extended by blocks of the substates.
+func (db *substateDB) putSubstates(w KeyValueWriter, substates ...*substate.Substate) error {
+	type tracked struct {
+		key                string
+		coverage, extended indexCoverage
+	}
+	var coverages []tracked
+	for _, index := range coveredIndexes {
+		if db.indexes&index.flag == 0 {
+			continue
+		}
+		coverage, err := db.getIndexCoverage(index.coverageKey)
+		if err != nil {
+			return err
+		}
+		coverages = append(coverages, tracked{index.coverageKey, coverage, coverage})
+	}
+
+	for _, ss := range substates {
+		// coverage is checked before ss is written to skip it in the search for unindexed substates
+		for i := range coverages {
+			if err := db.extendIndexCoverage(&coverages[i].extended, ss.Block); err != nil {
+				return err
+			}
+		}
+		if err := db.putSubstate(w, ss); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range coverages {
+		if c.extended == c.coverage {
+			continue
+		}
+		if err := putIndexCoverage(w, c.key, c.extended); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putSubstate writes given substate together with its codes and index entries into w.
+func (db *substateDB) putSubstate(w KeyValueWriter, ss *substate.Substate) error {
 	for i, account := range ss.InputSubstate {
-		err := db.PutCode(account.Code)
+		err := putCode(w, account.Code)
 		if err != nil {
 			return fmt.Errorf("cannot put preState code from substate-account %v block %v, %v tx into db; %w", i, ss.Block, ss.Transaction, err)
 		}
 	}
 
 	for i, account := range ss.OutputSubstate {
-		err := db.PutCode(account.Code)
+		err := putCode(w, account.Code)
 		if err != nil {
 			return fmt.Errorf("cannot put postState code from substate-account %v block %v, %v tx into db; %w", i, ss.Block, ss.Transaction, err)
 		}
 	}
 
 	if msg := ss.Message; msg.To == nil {
-		err := db.PutCode(msg.Data)
+		err := putCode(w, msg.Data)
 		if err != nil {
 			return fmt.Errorf("cannot put input data from substate block %v, %v tx into db; %v", ss.Block, ss.Transaction, err)
 		}
@@ -184,16 +376,116 @@ func (db *substateDB) PutSubstate(ss *substate.Substate) error {
 	key := SubstateDBKey(ss.Block, ss.Transaction)
 
 	substateRLP := rlp.NewRLP(ss)
+	if db.indexes&BlockHashIndex != 0 {
+		// hashes are stored within the block-hash table by putIndexes
+		substateRLP.Env.BlockHashes = nil
+	}
 	value, err := trlp.EncodeToBytes(substateRLP)
 	if err != nil {
 		return fmt.Errorf("cannot encode substate-rlp block %v, tx %v; %v", ss.Block, ss.Transaction, err)
 	}
 
-	return db.Put(key, value)
+	if err = w.Put(key, value); err != nil {
+		return err
+	}
+
+	return db.putIndexes(w, ss)
+}
+
+func (db *substateDB) GetReceiptsRoot(block uint64) (types.Hash, error) {
+	substates, err := db.GetBlockSubstates(block)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	txs := make([]int, 0, len(substates))
+	for tx := range substates {
+		txs = append(txs, tx)
+	}
+	sort.Ints(txs)
+
+	results := make([]*substate.Result, len(txs))
+	for i, tx := range txs {
+		if tx != i {
+			return types.Hash{}, fmt.Errorf("block %v misses substate of tx %v", block, i)
+		}
+		results[i] = substates[tx].Result
+	}
+
+	root, err := trie.ReceiptsRoot(results)
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("cannot rebuild receipts of block %v; %w", block, err)
+	}
+	return root, nil
 }
 
+// putIndexes writes index entries of all enabled indexes for given substate into w.
+func (db *substateDB) putIndexes(w KeyValueWriter, ss *substate.Substate) error {
+	if db.indexes&TxHashIndex != 0 && ss.Message != nil && ss.Message.TxHash != nil {
+		if err := putTxHash(w, *ss.Message.TxHash, ss.Block, ss.Transaction); err != nil {
+			return fmt.Errorf("cannot index tx hash of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+		}
+	}
+	if db.indexes&AddressIndex != 0 {
+		if err := putAddresses(w, ss); err != nil {
+			return fmt.Errorf("cannot index addresses of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+		}
+	}
+	if db.indexes&LogIndex != 0 {
+		if err := putLogs(w, ss); err != nil {
+			return fmt.Errorf("cannot index logs of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+		}
+	}
+	if db.indexes&BlockHashIndex != 0 {
+		if err := db.markBlockHashTable(w); err != nil {
+			return err
+		}
+		if err := putBlockHashes(w, ss); err != nil {
+			return fmt.Errorf("cannot store block hashes of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+		}
+	}
+	return nil
+}
+
+// DeleteSubstate deletes substate of given block and tx together with its entries in the
+// TxHashIndex, the AddressIndex and the LogIndex. Tx hash is only deleted if it is recorded in the substate.
 func (db *substateDB) DeleteSubstate(block uint64, tx int) error {
-	return db.Delete(SubstateDBKey(block, tx))
+	ss, err := db.GetSubstate(block, tx)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	batch := db.NewBatch()
+	if err = db.deleteIndexes(batch, ss); err != nil {
+		return err
+	}
+	if err = batch.Delete(SubstateDBKey(block, tx)); err != nil {
+		return err
+	}
+	if err = batch.Write(); err != nil {
+		return fmt.Errorf("cannot delete substate block: %v, tx: %v; %w", block, tx, err)
+	}
+	return nil
+}
+
+// deleteIndexes removes index entries of given substate written by putIndexes.
+// Block hashes are shared by all substates of the block and are kept.
+func (db *substateDB) deleteIndexes(w KeyValueWriter, ss *substate.Substate) error {
+	if ss.Message != nil && ss.Message.TxHash != nil {
+		if err := db.deleteTxHash(w, *ss.Message.TxHash, ss.Block, ss.Transaction); err != nil {
+			return fmt.Errorf("cannot delete tx hash of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+		}
+	}
+	if err := deleteAddresses(w, ss); err != nil {
+		return fmt.Errorf("cannot delete addresses of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+	}
+	if err := deleteLogs(w, ss); err != nil {
+		return fmt.Errorf("cannot delete logs of block %v, tx %v; %w", ss.Block, ss.Transaction, err)
+	}
+	return nil
 }
 
 // NewSubstateIterator returns iterator which iterates over Substates.
@@ -7,9 +7,15 @@ import (
 	"testing"
 
 	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/rlp"
 	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/trie"
 	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	trlp "github.com/Fantom-foundation/Substate/types/rlp"
 )
 
 var testSubstate = &substate.Substate{
@@ -48,6 +54,57 @@ func TestSubstateDB_PutSubstate(t *testing.T) {
 	}
 }
 
+func TestSubstateDB_PutSubstates(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.EnableIndexes(AddressIndex)
+
+	created := newTestSubstate(1, 1)
+	created.Message.To = nil
+	created.Message.Data = []byte{0x60}
+	if err = db.PutSubstates(newTestSubstate(1, 0), created); err != nil {
+		t.Fatal(err)
+	}
+
+	for tx := 0; tx < 2; tx++ {
+		if has, err := db.HasSubstate(1, tx); err != nil || !has {
+			t.Fatalf("substate 1_%v was not written; %v", tx, err)
+		}
+	}
+	if has, err := db.HasCode(hash.Keccak256Hash(created.Message.Data)); err != nil || !has {
+		t.Fatalf("init code was not written; %v", err)
+	}
+	if has, err := db.Has(AddressIndexDBKey(types.Address{2}, 1, 0)); err != nil || !has {
+		t.Fatalf("index was not written; %v", err)
+	}
+}
+
+func TestSubstateDB_GetReceiptsRoot(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, db, newTestSubstate(1, 0), newTestSubstate(1, 1), newTestSubstate(2, 1))
+
+	root, err := db.GetReceiptsRoot(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := trie.ReceiptsRoot([]*substate.Result{newTestSubstate(1, 0).Result, newTestSubstate(1, 1).Result})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root != want {
+		t.Fatalf("unexpected receipts root\ngot: %v\nwant: %v", root, want)
+	}
+
+	if _, err = db.GetReceiptsRoot(2); err == nil {
+		t.Fatal("block with missing transaction must be rejected")
+	}
+}
+
 func TestSubstateDB_HasSubstate(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
 	db, err := createDbAndPutSubstate(dbPath)
@@ -86,6 +143,114 @@ func TestSubstateDB_GetSubstate(t *testing.T) {
 	}
 }
 
+func TestSubstateDB_GetSubstateWithProfile(t *testing.T) {
+	dbPath := t.TempDir() + "test-db"
+	db, err := createDbAndPutSubstate(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, profile := range chain.Profiles() {
+		db.SetProfile(profile)
+
+		ss, err := db.GetSubstate(37_534_834, 1)
+		if err != nil {
+			t.Fatalf("%v: get substate returned error; %v", profile, err)
+		}
+		if err = ss.Equal(testSubstate); err != nil {
+			t.Fatalf("%v: substates are different; %v", profile, err)
+		}
+	}
+}
+
+func TestSubstateDB_GetSubstateWithProfileDecodesOldLayouts(t *testing.T) {
+	// mirror layouts of records written before Berlin and before London
+	type legacyEnv struct {
+		Coinbase    types.Address
+		Difficulty  *big.Int
+		GasLimit    uint64
+		Number      uint64
+		Timestamp   uint64
+		BlockHashes [][2]types.Hash
+	}
+	type legacyMessage struct {
+		Nonce        uint64
+		CheckNonce   bool
+		GasPrice     *big.Int
+		Gas          uint64
+		From         types.Address
+		To           *types.Address `rlp:"nil"`
+		Value        *big.Int
+		Data         []byte
+		InitCodeHash *types.Hash `rlp:"nil"`
+	}
+	type berlinMessage struct {
+		Nonce        uint64
+		CheckNonce   bool
+		GasPrice     *big.Int
+		Gas          uint64
+		From         types.Address
+		To           *types.Address `rlp:"nil"`
+		Value        *big.Int
+		Data         []byte
+		InitCodeHash *types.Hash `rlp:"nil"`
+		AccessList   types.AccessList
+	}
+	type layout[M any] struct {
+		InputAlloc  rlp.WorldState
+		OutputAlloc rlp.WorldState
+		Env         *legacyEnv
+		Message     *M
+		Result      *rlp.Result
+	}
+
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetProfile(chain.Opera)
+
+	to := types.Address{2}
+	msg := legacyMessage{Nonce: 1, CheckNonce: true, GasPrice: big.NewInt(3), Gas: 21_000, From: types.Address{1}, To: &to, Value: big.NewInt(4)}
+	accessList := types.AccessList{{Address: to, StorageKeys: []types.Hash{{5}}}}
+	alloc := rlp.NewWorldState(substate.NewWorldState().Add(types.Address{1}, 1, big.NewInt(6), nil))
+	result := rlp.NewResult(substate.NewResult(1, types.Bloom{}, []*types.Log{}, types.Address{}, 21_000))
+
+	records := map[uint64]any{
+		1:          layout[legacyMessage]{alloc, alloc, &legacyEnv{Difficulty: big.NewInt(1), GasLimit: 30_000, Number: 1}, &msg, result},
+		37_455_223: layout[berlinMessage]{alloc, alloc, &legacyEnv{Difficulty: big.NewInt(1), GasLimit: 30_000, Number: 37_455_223}, &berlinMessage{msg.Nonce, msg.CheckNonce, msg.GasPrice, msg.Gas, msg.From, msg.To, msg.Value, msg.Data, nil, accessList}, result},
+	}
+	for block, record := range records {
+		value, err := trlp.EncodeToBytes(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = db.Put(SubstateDBKey(block, 0), value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for block := range records {
+		ss, err := db.GetSubstate(block, 0)
+		if err != nil {
+			t.Fatalf("block %v: get substate returned error; %v", block, err)
+		}
+		if ss.Env.Number != block || ss.Env.BaseFee != nil || ss.Message.GasPrice.Int64() != 3 || ss.Message.Value.Int64() != 4 || *ss.Message.To != to {
+			t.Fatalf("block %v: unexpected substate\n%v", block, ss)
+		}
+		if ss.OutputSubstate[types.Address{1}].Balance.Int64() != 6 || ss.Result.GasUsed != 21_000 {
+			t.Fatalf("block %v: unexpected state or result\n%v", block, ss)
+		}
+		wantAccessList := 0
+		if block == 37_455_223 {
+			wantAccessList = 1
+		}
+		if len(ss.Message.AccessList) != wantAccessList {
+			t.Fatalf("block %v: unexpected access list %v", block, ss.Message.AccessList)
+		}
+	}
+}
+
 func TestSubstateDB_DeleteSubstate(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
 	db, err := createDbAndPutSubstate(dbPath)
@@ -112,6 +277,55 @@ func TestSubstateDB_DeleteSubstate(t *testing.T) {
 	}
 }
 
+func TestSubstateDB_DeleteSubstateDeletesIndexEntries(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.EnableIndexes(TxHashIndex | AddressIndex | LogIndex)
+
+	txHash := types.Hash{0xaa}
+	ss := newTestSubstateWithLogs(1, 0, &types.Log{Address: types.Address{0xbb}, Topics: []types.Hash{{0xcc}}})
+	ss.Message.TxHash = &txHash
+	kept := newTestSubstateWithLogs(1, 1, &types.Log{Address: types.Address{0xbb}, Topics: []types.Hash{{0xcc}}})
+	putTestSubstates(t, db, ss, kept)
+
+	if err = db.DeleteSubstate(1, 0); err != nil {
+		t.Fatalf("delete substate returned error; %v", err)
+	}
+
+	if _, _, err = db.GetTxPosition(txHash); !errors.Is(err, leveldb.ErrNotFound) {
+		t.Fatalf("unexpected error of deleted tx hash\ngot: %v\nwant: %v", err, leveldb.ErrNotFound)
+	}
+
+	for _, prefix := range []string{AddressIndexDBPrefix, LogIndexDBPrefix} {
+		iter := db.backend.NewIterator(util.BytesPrefix([]byte(prefix)), nil)
+		for iter.Next() {
+			var tx int
+			if prefix == AddressIndexDBPrefix {
+				_, _, tx, err = DecodeAddressIndexDBKey(iter.Key())
+			} else {
+				_, _, tx, err = DecodeLogIndexDBKey(iter.Key())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx != 1 {
+				t.Fatalf("index entry %v of deleted substate was kept", iter.Key())
+			}
+		}
+		iter.Release()
+	}
+
+	logs, err := db.GetLogs(LogFilter{FromBlock: 1, ToBlock: 1, Addresses: []types.Address{{0xbb}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("unexpected number of logs\ngot: %v\nwant: 1", len(logs))
+	}
+}
+
 func TestSubstateDB_getLastBlock(t *testing.T) {
 	dbPath := t.TempDir() + "test-db"
 	db, err := createDbAndPutSubstate(dbPath)
@@ -5,7 +5,6 @@ import (
 
 	"github.com/syndtr/goleveldb/leveldb/util"
 
-	"github.com/Fantom-foundation/Substate/rlp"
 	"github.com/Fantom-foundation/Substate/substate"
 )
 
@@ -33,12 +32,12 @@ func (i *substateIterator) decode(data rawEntry) (*substate.Substate, error) {
 		return nil, fmt.Errorf("invalid substate key: %v; %w", key, err)
 	}
 
-	rlpSubstate, err := rlp.Decode(value)
+	rlpSubstate, err := i.db.decode(value, block)
 	if err != nil {
 		return nil, err
 	}
 
-	return rlpSubstate.ToSubstate(i.db.GetCode, block, tx)
+	return i.db.toSubstate(rlpSubstate, block, tx)
 }
 
 func (i *substateIterator) start(numWorkers int) {
@@ -13,6 +13,20 @@ import (
 )
 
 var (
+	SubstateDbFlag = cli.PathFlag{
+		Name:     "substate-db",
+		Usage:    "Path to the substate database",
+		Required: true,
+	}
+	FirstBlockFlag = cli.Uint64Flag{
+		Name:  "first",
+		Usage: "First block to process",
+	}
+	LastBlockFlag = cli.Uint64Flag{
+		Name:     "last",
+		Usage:    "Last block to process (inclusive)",
+		Required: true,
+	}
 	WorkersFlag = cli.IntFlag{
 		Name:  "workers",
 		Usage: "Number of worker threads that execute in parallel",
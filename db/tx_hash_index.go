@@ -0,0 +1,138 @@
+package db
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const TxHashDBPrefix = "1t" // TxHashDBPrefix + txHash (256-bit) -> block (64-bit) + tx (64-bit)
+
+var BackfillTxHashIndexCommand = cli.Command{
+	Name:   "backfill-tx-hash-index",
+	Usage:  "Indexes recorded hashes of all transactions between first and last block",
+	Action: backfillTxHashIndexAction,
+	Flags:  []cli.Flag{&SubstateDbFlag, &FirstBlockFlag, &LastBlockFlag, &WorkersFlag},
+}
+
+// TxHashFunc returns the hash of the transaction recorded in given substate.
+type TxHashFunc func(block uint64, tx int, substate *substate.Substate) (types.Hash, error)
+
+// GetSubstateByTxHash returns substate of transaction with given hash if it is indexed within DB.
+func (db *substateDB) GetSubstateByTxHash(txHash types.Hash) (*substate.Substate, error) {
+	block, tx, err := db.GetTxPosition(txHash)
+	if err != nil {
+		return nil, err
+	}
+	return db.GetSubstate(block, tx)
+}
+
+// GetTxPosition returns block and tx number of transaction with given hash if it is indexed within DB.
+func (db *substateDB) GetTxPosition(txHash types.Hash) (uint64, int, error) {
+	val, err := db.Get(TxHashDBKey(txHash))
+	if err != nil {
+		return 0, 0, fmt.Errorf("cannot get position of tx %s; %w", txHash, err)
+	}
+	return DecodeTxHashDBValue(val)
+}
+
+func (db *substateDB) PutTxHash(txHash types.Hash, block uint64, tx int) error {
+	return putTxHash(db, txHash, block, tx)
+}
+
+func (db *substateDB) DeleteTxHash(txHash types.Hash) error {
+	return db.Delete(TxHashDBKey(txHash))
+}
+
+// BackfillTxHashIndex indexes hashes of all transactions between first and last block (including first and last).
+// Hash recorded in Message.TxHash is preferred, getTxHash is used for substates without recorded hash.
+// getTxHash may be nil if every substate within the range has its hash recorded.
+func (db *substateDB) BackfillTxHashIndex(first, last uint64, workers int, getTxHash TxHashFunc) error {
+	pool := &SubstateTaskPool{
+		Name: "backfill-tx-hash-index",
+		TaskFunc: func(block uint64, tx int, ss *substate.Substate, _ *SubstateTaskPool) error {
+			if ss.Message.TxHash != nil {
+				return db.PutTxHash(*ss.Message.TxHash, block, tx)
+			}
+			if getTxHash == nil {
+				return fmt.Errorf("substate block %v, tx %v has no recorded tx hash", block, tx)
+			}
+			txHash, err := getTxHash(block, tx, ss)
+			if err != nil {
+				return fmt.Errorf("cannot get tx hash; %w", err)
+			}
+			return db.PutTxHash(txHash, block, tx)
+		},
+
+		First: first,
+		Last:  last,
+
+		Workers: workers,
+		DB:      db,
+	}
+
+	return pool.Execute()
+}
+
+// backfillTxHashIndexAction indexes hashes recorded in Message.TxHash,
+// substates recorded without the hash make the command fail.
+func backfillTxHashIndexAction(ctx *cli.Context) error {
+	first, last := ctx.Uint64(FirstBlockFlag.Name), ctx.Uint64(LastBlockFlag.Name)
+	if first > last {
+		return fmt.Errorf("invalid block range %v-%v", first, last)
+	}
+
+	db, err := NewDefaultSubstateDB(ctx.Path(SubstateDbFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.BackfillTxHashIndex(first, last, ctx.Int(WorkersFlag.Name), nil)
+}
+
+func putTxHash(w KeyValueWriter, txHash types.Hash, block uint64, tx int) error {
+	value := make([]byte, 16)
+	binary.BigEndian.PutUint64(value[0:8], block)
+	binary.BigEndian.PutUint64(value[8:16], uint64(tx))
+	return w.Put(TxHashDBKey(txHash), value)
+}
+
+// deleteTxHash removes txHash from the TxHashIndex if it points to given block and tx.
+func (db *substateDB) deleteTxHash(w KeyValueWriter, txHash types.Hash, block uint64, tx int) error {
+	b, t, err := db.GetTxPosition(txHash)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if b != block || t != tx {
+		return nil
+	}
+	return w.Delete(TxHashDBKey(txHash))
+}
+
+// TxHashDBKey returns TxHashDBPrefix with appended
+// txHash creating key used in baseDB for the TxHashIndex.
+func TxHashDBKey(txHash types.Hash) []byte {
+	prefix := []byte(TxHashDBPrefix)
+	return append(prefix, txHash[:]...)
+}
+
+// DecodeTxHashDBValue decodes value of the TxHashIndex back to block and tx number.
+func DecodeTxHashDBValue(value []byte) (block uint64, tx int, err error) {
+	if len(value) != 16 {
+		err = fmt.Errorf("invalid length of tx hash db value: %v", len(value))
+		return
+	}
+	block = binary.BigEndian.Uint64(value[0:8])
+	tx = int(binary.BigEndian.Uint64(value[8:16]))
+	return
+}
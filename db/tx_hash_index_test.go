@@ -0,0 +1,132 @@
+package db
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestSubstateDB_PutSubstateMaintainsTxHashIndex(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.EnableIndexes(TxHashIndex)
+
+	ss := newTestSubstate(5, 3)
+	txHash := types.Hash{0xab}
+	ss.Message.TxHash = &txHash
+	// decoded message carries a zero blob fee cap
+	ss.Message.BlobGasFeeCap = big.NewInt(0)
+	putTestSubstates(t, db, ss)
+
+	block, tx, err := db.GetTxPosition(txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != 5 || tx != 3 {
+		t.Fatalf("unexpected position\ngot: %v_%v\nwant: 5_3", block, tx)
+	}
+
+	got, err := db.GetSubstateByTxHash(txHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = got.Equal(ss); err != nil {
+		t.Fatalf("substates are different; %v", err)
+	}
+}
+
+func TestSubstateDB_PutSubstateDoesNotIndexWhenDisabled(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ss := newTestSubstate(5, 3)
+	txHash := types.Hash{0xab}
+	ss.Message.TxHash = &txHash
+	putTestSubstates(t, db, ss)
+
+	_, err = db.GetSubstateByTxHash(txHash)
+	if !errors.Is(err, leveldb.ErrNotFound) {
+		t.Fatalf("unexpected error, got: %v, want: %v", err, leveldb.ErrNotFound)
+	}
+}
+
+func TestSubstateDB_BackfillTxHashIndex(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, db, newTestSubstate(1, 0), newTestSubstate(1, 1), newTestSubstate(2, 0))
+
+	hashOf := func(block uint64, tx int, _ *substate.Substate) (types.Hash, error) {
+		return types.Hash{byte(block), byte(tx)}, nil
+	}
+	if err = db.BackfillTxHashIndex(1, 2, 2, hashOf); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pos := range [][2]int{{1, 0}, {1, 1}, {2, 0}} {
+		block, tx, err := db.GetTxPosition(types.Hash{byte(pos[0]), byte(pos[1])})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if block != uint64(pos[0]) || tx != pos[1] {
+			t.Fatalf("unexpected position\ngot: %v_%v\nwant: %v_%v", block, tx, pos[0], pos[1])
+		}
+	}
+}
+
+func TestSubstateDB_BackfillTxHashIndexFailsWithoutHashSource(t *testing.T) {
+	db, err := newSubstateDB(t.TempDir()+"test-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	putTestSubstates(t, db, newTestSubstate(1, 0))
+
+	if err = db.BackfillTxHashIndex(1, 1, 1, nil); err == nil {
+		t.Fatal("backfill must fail")
+	}
+}
+
+func TestBackfillTxHashIndexCommand(t *testing.T) {
+	path := t.TempDir() + "test-db"
+	db, err := newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := newTestSubstate(2, 0)
+	recorded.Message.TxHash = &types.Hash{2}
+	putTestSubstates(t, db, newTestSubstate(1, 0), recorded)
+	db.Close()
+
+	app := &cli.App{Commands: []*cli.Command{&BackfillTxHashIndexCommand}}
+	if err = app.Run([]string{"substate", "backfill-tx-hash-index", "--substate-db", path, "--first", "2", "--last", "2", "--workers", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	// block 1 has no recorded hash
+	if err = app.Run([]string{"substate", "backfill-tx-hash-index", "--substate-db", path, "--last", "2"}); err == nil {
+		t.Fatal("command must fail for substates without recorded hash")
+	}
+
+	db, err = newSubstateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	block, tx, err := db.GetTxPosition(types.Hash{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block != 2 || tx != 0 {
+		t.Fatalf("unexpected position\ngot: %v_%v\nwant: 2_0", block, tx)
+	}
+}
@@ -2,7 +2,6 @@ package db
 
 import (
 	"encoding/binary"
-	"errors"
 	"fmt"
 
 	"github.com/syndtr/goleveldb/leveldb"
@@ -43,6 +42,9 @@ type UpdateDB interface {
 	NewUpdateSetIterator(start, end uint64) Iterator[*updateset.UpdateSet]
 
 	PutMetadata(interval, size uint64) error
+
+	// GetMetadata returns interval and size of UpdateSets recorded by PutMetadata.
+	GetMetadata() (interval uint64, size uint64, err error)
 }
 
 // NewDefaultUpdateDB creates new instance of UpdateDB with default options.
@@ -96,10 +98,10 @@ func (db *updateDB) GetFirstKey() (uint64, error) {
 func (db *updateDB) GetLastKey() (uint64, error) {
 	r := util.BytesPrefix([]byte(UpdateDBPrefix))
 
-	iter := db.backend.NewIterator(r, nil)
+	iter := db.backend.NewIterator(r, db.ro)
 	defer iter.Release()
 
-	for iter.Next() {
+	if iter.Last() {
 		lastBlock, err := DecodeUpdateSetKey(iter.Key())
 		if err != nil {
 			return 0, fmt.Errorf("cannot decode updateset key; %v", err)
@@ -108,7 +110,7 @@ func (db *updateDB) GetLastKey() (uint64, error) {
 		return lastBlock, nil
 	}
 
-	return 0, errors.New("no updateset found")
+	return 0, leveldb.ErrNotFound
 }
 
 func (db *updateDB) HasUpdateSet(block uint64) (bool, error) {
@@ -163,3 +163,25 @@ func createDbAndPutUpdateSet(dbPath string) (*updateDB, error) {
 
 	return db, nil
 }
+
+func TestUpdateDB_GetLastKeyOfMultipleUpdateSets(t *testing.T) {
+	dbPath := t.TempDir() + "test-db"
+	db, err := createDbAndPutUpdateSet(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.PutUpdateSet(&updateset.UpdateSet{WorldState: substate.NewWorldState(), Block: 10}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := db.GetLastKey()
+	if err != nil {
+		t.Fatalf("cannot get last key; %v", err)
+	}
+
+	if got != 10 {
+		t.Fatalf("incorrect last key\nwant: %v\ngot: %v", 10, got)
+	}
+}
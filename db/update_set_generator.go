@@ -0,0 +1,224 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/updateset"
+)
+
+// UpdateSetGenerator creates UpdateSets from Substates. An UpdateSet contains all changes
+// of the world state since the previous UpdateSet up to and including its block.
+// Applying an UpdateSet means deleting its DeletedAccounts and then merging its WorldState.
+type UpdateSetGenerator struct {
+	SubstateDB SubstateDB
+	UpdateDB   UpdateDB
+
+	// DestroyedAccountDB is optional. If set, destroyed and resurrected accounts are
+	// removed from the UpdateSet and recorded as its DeletedAccounts.
+	DestroyedAccountDB *DestroyedAccountDB
+
+	// Interval is the number of blocks between two UpdateSets. UpdateSets are created
+	// for every block which is a multiple of the Interval.
+	Interval uint64
+
+	// MaxSize is the estimated size of an UpdateSet in bytes which causes the UpdateSet
+	// to be created at the end of the current block, before the Interval is reached.
+	// Zero disables the limit.
+	MaxSize uint64
+
+	// Workers is the number of workers decoding Substates, at least one worker is used.
+	Workers int
+}
+
+// Generate creates UpdateSets from all Substates between first and last block (including first and last).
+// UpdateSets are created for every multiple of the Interval within the range and for the last block.
+// If the UpdateDB already contains UpdateSets, generation continues after the last of them and first is ignored.
+// A last UpdateSet between two multiples of the Interval is replaced, so only the last UpdateSet of
+// the UpdateDB may be misaligned unless MaxSize is set. First is used if that UpdateSet is the only one.
+func (g *UpdateSetGenerator) Generate(first, last uint64) error {
+	if g.Interval == 0 {
+		return errors.New("update-set interval must be greater than zero")
+	}
+
+	start, err := g.getStartBlock(first, last)
+	if err != nil {
+		return err
+	}
+	if start > last {
+		return nil
+	}
+
+	if err = g.UpdateDB.PutMetadata(g.Interval, g.MaxSize); err != nil {
+		return fmt.Errorf("cannot put update-set metadata; %w", err)
+	}
+
+	state := &updateSetState{
+		update:     substate.NewWorldState(),
+		deleted:    make(map[types.Address]struct{}),
+		checkpoint: (start + g.Interval - 1) / g.Interval * g.Interval,
+		lastPut:    start - 1,
+	}
+
+	iter := g.SubstateDB.NewSubstateIterator(int(start), max(g.Workers, 1))
+	defer iter.Release()
+
+	currentBlock := start
+	for iter.Next() {
+		ss := iter.Value()
+		if ss.Block > last {
+			break
+		}
+
+		if ss.Block != currentBlock {
+			if err = g.finishBlock(state, currentBlock, ss.Block); err != nil {
+				return err
+			}
+			currentBlock = ss.Block
+		}
+
+		if err = g.apply(state, ss); err != nil {
+			return err
+		}
+	}
+
+	if err = iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate substates; %w", err)
+	}
+
+	for state.checkpoint <= last {
+		if err = g.put(state, state.checkpoint); err != nil {
+			return err
+		}
+		state.checkpoint += g.Interval
+	}
+	if state.lastPut != last {
+		return g.put(state, last)
+	}
+	return nil
+}
+
+// updateSetState holds changes which were not yet inserted into the UpdateDB.
+type updateSetState struct {
+	update     substate.WorldState
+	deleted    map[types.Address]struct{}
+	size       uint64
+	checkpoint uint64 // block of next UpdateSet created by the Interval
+	lastPut    uint64 // block of last inserted UpdateSet
+}
+
+// getStartBlock returns the first block of generation. If the last UpdateSet is not at a multiple
+// of the Interval, it is the tail of a previous generation. The tail is deleted and blocks since the
+// preceding UpdateSet are generated again, unless the generation ends before the tail.
+func (g *UpdateSetGenerator) getStartBlock(first, last uint64) (uint64, error) {
+	lastKey, err := g.UpdateDB.GetLastKey()
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return first, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cannot get last update-set; %w", err)
+	}
+
+	interval, _, err := g.UpdateDB.GetMetadata()
+	if err != nil && !errors.Is(err, leveldb.ErrNotFound) {
+		return 0, fmt.Errorf("cannot get update-set metadata; %w", err)
+	}
+	if err == nil && interval != g.Interval {
+		return 0, fmt.Errorf("update-set interval %v differs from interval %v of existing update-sets", g.Interval, interval)
+	}
+
+	if lastKey%g.Interval == 0 || last <= lastKey {
+		return lastKey + 1, nil
+	}
+
+	start := first
+	iter := g.UpdateDB.NewIterator([]byte(UpdateDBPrefix), nil)
+	if iter.Last() && iter.Prev() {
+		prev, err := DecodeUpdateSetKey(iter.Key())
+		if err != nil {
+			iter.Release()
+			return 0, fmt.Errorf("invalid update-set key: %v; %w", iter.Key(), err)
+		}
+		start = prev + 1
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		return 0, fmt.Errorf("cannot iterate update-sets; %w", err)
+	}
+
+	if err = g.UpdateDB.DeleteUpdateSet(lastKey); err != nil {
+		return 0, fmt.Errorf("cannot delete update-set tail block: %v; %w", lastKey, err)
+	}
+	return start, nil
+}
+
+// finishBlock inserts UpdateSets which are due after all transactions of block are applied.
+// The next applied block is passed as next.
+func (g *UpdateSetGenerator) finishBlock(state *updateSetState, block, next uint64) error {
+	if state.checkpoint < next {
+		// every checkpoint gets its UpdateSet even if there are no changes
+		for state.checkpoint < next {
+			if err := g.put(state, state.checkpoint); err != nil {
+				return err
+			}
+			state.checkpoint += g.Interval
+		}
+		return nil
+	}
+
+	if g.MaxSize > 0 && state.size >= g.MaxSize {
+		return g.put(state, block)
+	}
+	return nil
+}
+
+// apply merges changes of given substate into the state.
+func (g *UpdateSetGenerator) apply(state *updateSetState, ss *substate.Substate) error {
+	if g.DestroyedAccountDB != nil {
+		destroyed, resurrected, err := g.DestroyedAccountDB.GetDestroyedAccounts(ss.Block, ss.Transaction)
+		if err != nil {
+			return fmt.Errorf("cannot get destroyed accounts block: %v, tx: %v; %w", ss.Block, ss.Transaction, err)
+		}
+
+		for _, addr := range append(destroyed, resurrected...) {
+			delete(state.update, addr)
+			state.deleted[addr] = struct{}{}
+		}
+	}
+
+	state.size += state.update.EstimateIncrementalSize(ss.OutputSubstate)
+	state.update.Merge(ss.OutputSubstate)
+	return nil
+}
+
+// put inserts changes of the state as an UpdateSet for given block and resets the state.
+func (g *UpdateSetGenerator) put(state *updateSetState, block uint64) error {
+	deletedAccounts := make([]types.Address, 0, len(state.deleted))
+	for addr := range state.deleted {
+		deletedAccounts = append(deletedAccounts, addr)
+	}
+	sort.Slice(deletedAccounts, func(i, j int) bool {
+		return bytes.Compare(deletedAccounts[i][:], deletedAccounts[j][:]) < 0
+	})
+
+	us := &updateset.UpdateSet{
+		WorldState:      state.update,
+		Block:           block,
+		DeletedAccounts: deletedAccounts,
+	}
+	if err := g.UpdateDB.PutUpdateSet(us, deletedAccounts); err != nil {
+		return fmt.Errorf("cannot put update-set block: %v; %w", block, err)
+	}
+
+	state.update = substate.NewWorldState()
+	state.deleted = make(map[types.Address]struct{})
+	state.size = 0
+	state.lastPut = block
+	return nil
+}
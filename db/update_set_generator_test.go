@@ -0,0 +1,180 @@
+package db
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/updateset"
+)
+
+func TestUpdateSetGenerator_Generate(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, sdb, newTestSubstate(1, 0), newTestSubstate(2, 0), newTestSubstate(3, 0), newTestSubstate(5, 0))
+	if err = ddb.SetDestroyedAccounts(3, 0, []types.Address{{2}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, DestroyedAccountDB: ddb, Interval: 2, Workers: 1}
+	if err = g.Generate(1, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	sets := getUpdateSets(t, udb)
+	if len(sets) != 3 || sets[0].Block != 2 || sets[1].Block != 4 || sets[2].Block != 6 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+
+	want := newTestSubstate(0, 0).OutputSubstate
+	if !sets[0].WorldState.Equal(want) || len(sets[0].DeletedAccounts) != 0 {
+		t.Fatalf("unexpected update-set of block 2\ngot: %v\nwant: %v", sets[0].WorldState, want)
+	}
+
+	// account 2 is destroyed in block 3 and merged back by its output
+	if !sets[1].WorldState.Equal(want) || len(sets[1].DeletedAccounts) != 1 || sets[1].DeletedAccounts[0] != (types.Address{2}) {
+		t.Fatalf("unexpected update-set of block 4\ngot: %v %v", sets[1].WorldState, sets[1].DeletedAccounts)
+	}
+
+	interval, size, err := udb.GetMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if interval != 2 || size != 0 {
+		t.Fatalf("unexpected metadata\ngot: %v %v\nwant: 2 0", interval, size)
+	}
+}
+
+func TestUpdateSetGenerator_GenerateContinuesExistingUpdateDB(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, sdb, newTestSubstate(1, 0), newTestSubstate(3, 0))
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, Interval: 4, Workers: 1}
+	if err = g.Generate(0, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, sdb, newTestSubstate(6, 0))
+	if err = g.Generate(0, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tail at block 2 is replaced by the update-set at block 4
+	sets := getUpdateSets(t, udb)
+	if len(sets) != 3 || sets[0].Block != 0 || sets[1].Block != 4 || sets[2].Block != 8 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+	if len(sets[0].WorldState) != 0 || len(sets[1].WorldState) != 2 || len(sets[2].WorldState) != 2 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+
+	problems, err := ValidateUpdateSets(udb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+
+	g.Interval = 2
+	if err = g.Generate(0, 10); err == nil {
+		t.Fatal("generate must fail when interval differs")
+	}
+}
+
+func TestUpdateSetGenerator_GenerateReplacesOnlyTail(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tailed := newTestSubstate(12, 0)
+	tailed.OutputSubstate[types.Address{0x12}] = substate.NewAccount(0, big.NewInt(1), nil)
+	putTestSubstates(t, sdb, newTestSubstate(5, 0), tailed, newTestSubstate(25, 0))
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, Interval: 10, Workers: 1}
+	if err = g.Generate(0, 15); err != nil {
+		t.Fatal(err)
+	}
+	// generation ending before the tail keeps it
+	if err = g.Generate(0, 14); err != nil {
+		t.Fatal(err)
+	}
+	if sets := getUpdateSets(t, udb); len(sets) != 3 || sets[2].Block != 15 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+
+	if err = g.Generate(0, 30); err != nil {
+		t.Fatal(err)
+	}
+	sets := getUpdateSets(t, udb)
+	if len(sets) != 4 || sets[0].Block != 0 || sets[1].Block != 10 || sets[2].Block != 20 || sets[3].Block != 30 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+	// changes of block 12 covered by the replaced tail are kept
+	if _, found := sets[2].WorldState[types.Address{0x12}]; !found {
+		t.Fatalf("unexpected update-set of block 20: %v", sets[2])
+	}
+}
+
+func TestUpdateSetGenerator_GenerateRespectsMaxSize(t *testing.T) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, sdb, newTestSubstate(1, 0), newTestSubstate(1, 1), newTestSubstate(2, 0), newTestSubstate(3, 0))
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, Interval: 100, MaxSize: 1, Workers: 1}
+	if err = g.Generate(1, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// every block exceeds the size, but update-sets are created only at the end of blocks
+	sets := getUpdateSets(t, udb)
+	if len(sets) != 3 || sets[0].Block != 1 || sets[1].Block != 2 || sets[2].Block != 3 {
+		t.Fatalf("unexpected update-sets: %v", sets)
+	}
+}
+
+func getUpdateSets(t *testing.T, db *updateDB) []*updateset.UpdateSet {
+	iter := db.NewUpdateSetIterator(0, 1000)
+	defer iter.Release()
+
+	var sets []*updateset.UpdateSet
+	for iter.Next() {
+		sets = append(sets, iter.Value())
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	return sets
+}
@@ -1,7 +1,6 @@
 package db
 
 import (
-	"encoding/binary"
 	"fmt"
 
 	"github.com/syndtr/goleveldb/leveldb/util"
@@ -11,11 +10,8 @@ import (
 )
 
 func newUpdateSetIterator(db *updateDB, start, end uint64) *updateSetIterator {
-	num := make([]byte, 4)
-	binary.BigEndian.PutUint32(num, uint32(start))
-
 	r := util.BytesPrefix([]byte(UpdateDBPrefix))
-	r.Start = append(r.Start, num...)
+	r.Start = append(r.Start, BlockToBytes(start)...)
 
 	return &updateSetIterator{
 		iterator: newIterator[*updateset.UpdateSet](db.backend.NewIterator(r, db.ro)),
@@ -96,7 +92,11 @@ func (i *updateSetIterator) start(_ int) {
 				return
 			}
 
-			i.resultCh <- us
+			select {
+			case <-i.stopCh:
+				return
+			case i.resultCh <- us:
+			}
 		}
 	}()
 }
@@ -0,0 +1,304 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/rlp"
+	"github.com/Fantom-foundation/Substate/types"
+	trlp "github.com/Fantom-foundation/Substate/types/rlp"
+	"github.com/Fantom-foundation/Substate/updateset"
+)
+
+var (
+	UpdateDbFlag = cli.PathFlag{
+		Name:     "update-db",
+		Usage:    "Path to the update-set database",
+		Required: true,
+	}
+	DeletionDbFlag = cli.PathFlag{
+		Name:  "deletion-db",
+		Usage: "Path to the database of destroyed accounts",
+	}
+
+	ValidateUpdateSetsCommand = cli.Command{
+		Name:   "validate-update-sets",
+		Usage:  "Reports gaps, misaligned update-sets, metadata mismatches, missing and orphaned code",
+		Action: validateUpdateSetsAction,
+		Flags:  []cli.Flag{&UpdateDbFlag, &DeletionDbFlag},
+	}
+)
+
+// UpdateSetProblemKind describes a problem found by ValidateUpdateSets.
+type UpdateSetProblemKind int
+
+const (
+	// MetadataMismatch means the metadata is missing or invalid.
+	MetadataMismatch UpdateSetProblemKind = iota
+	// UpdateSetGap means there is no UpdateSet for a block which is a multiple of the interval.
+	UpdateSetGap
+	// MisalignedUpdateSet means there is an UpdateSet between two intervals although size limit is disabled.
+	MisalignedUpdateSet
+	// DeletedAccountsMismatch means DeletedAccounts differ from accounts destroyed or resurrected within the covered blocks.
+	DeletedAccountsMismatch
+	// MissingCode means code of an account within an UpdateSet is not present.
+	MissingCode
+	// OrphanedCode means code is not referenced by any UpdateSet or by any substate within the same backend.
+	OrphanedCode
+)
+
+func (k UpdateSetProblemKind) String() string {
+	switch k {
+	case MetadataMismatch:
+		return "metadata mismatch"
+	case UpdateSetGap:
+		return "gap"
+	case MisalignedUpdateSet:
+		return "misaligned update-set"
+	case DeletedAccountsMismatch:
+		return "deleted accounts mismatch"
+	case MissingCode:
+		return "missing code"
+	case OrphanedCode:
+		return "orphaned code"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(k))
+	}
+}
+
+// UpdateSetProblem is a single problem found by ValidateUpdateSets.
+type UpdateSetProblem struct {
+	Kind    UpdateSetProblemKind
+	Block   uint64 // block of the UpdateSet or of the missing UpdateSet, not set for OrphanedCode
+	Message string
+}
+
+func (p UpdateSetProblem) String() string {
+	if p.Kind == OrphanedCode || p.Kind == MetadataMismatch {
+		return fmt.Sprintf("%v: %v", p.Kind, p.Message)
+	}
+	return fmt.Sprintf("%v: %v at block %v", p.Kind, p.Message, p.Block)
+}
+
+// ValidateUpdateSets checks UpdateSets of udb against their metadata and their code.
+// If ddb is not nil, DeletedAccounts of every UpdateSet are compared with accounts destroyed
+// or resurrected since the previous UpdateSet. The first UpdateSet is not compared
+// since the start of its range is unknown.
+// Code is orphaned if it is referenced neither by an UpdateSet nor by a substate stored within
+// the same backend, as UpdateSets and substates share their codes within an aida-db.
+// Note: Every UpdateSet covers blocks since the previous UpdateSet, hence UpdateSets cannot overlap.
+// An UpdateSet which replaces a multiple of the interval is reported as a gap. The UpdateSetGenerator
+// replaces its tail when extending generation, so only the last UpdateSet may end between two intervals.
+func ValidateUpdateSets(udb UpdateDB, ddb *DestroyedAccountDB) ([]UpdateSetProblem, error) {
+	var problems []UpdateSetProblem
+	report := func(kind UpdateSetProblemKind, block uint64, format string, args ...any) {
+		problems = append(problems, UpdateSetProblem{Kind: kind, Block: block, Message: fmt.Sprintf(format, args...)})
+	}
+
+	interval, size, err := udb.GetMetadata()
+	if errors.Is(err, leveldb.ErrNotFound) {
+		report(MetadataMismatch, 0, "metadata is missing")
+	} else if err != nil {
+		return nil, fmt.Errorf("cannot get update-set metadata; %w", err)
+	} else if interval == 0 {
+		report(MetadataMismatch, 0, "interval is zero")
+	}
+
+	referenced := make(map[types.Hash]struct{})
+	var blocks []uint64
+
+	iter := udb.NewIterator([]byte(UpdateDBPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		block, err := DecodeUpdateSetKey(iter.Key())
+		if err != nil {
+			return nil, fmt.Errorf("invalid update-set key: %v; %w", iter.Key(), err)
+		}
+
+		var us updateset.UpdateSetRLP
+		if err = trlp.DecodeBytes(iter.Value(), &us); err != nil {
+			return nil, fmt.Errorf("cannot decode update-set block: %v; %w", block, err)
+		}
+
+		for i, acc := range us.WorldState.Accounts {
+			if _, found := referenced[acc.CodeHash]; found {
+				continue
+			}
+			has, err := udb.HasCode(acc.CodeHash)
+			if err != nil {
+				return nil, err
+			}
+			if !has && acc.CodeHash != emptyCodeHash {
+				report(MissingCode, block, "code %s of account %s is missing", acc.CodeHash, us.WorldState.Addresses[i])
+				continue
+			}
+			referenced[acc.CodeHash] = struct{}{}
+		}
+
+		if ddb != nil && len(blocks) > 0 {
+			deleted, err := ddb.getDeletedAccountsInRange(blocks[len(blocks)-1]+1, block)
+			if err != nil {
+				return nil, err
+			}
+			if !sameAddresses(deleted, us.DeletedAccounts) {
+				report(DeletedAccountsMismatch, block, "got %v deleted accounts, destroyed or resurrected %v", len(us.DeletedAccounts), len(deleted))
+			}
+		}
+
+		blocks = append(blocks, block)
+	}
+	if err = iter.Error(); err != nil {
+		return nil, fmt.Errorf("cannot iterate update-sets; %w", err)
+	}
+
+	if interval > 0 && len(blocks) > 0 {
+		problems = append(problems, checkContinuity(blocks, interval, size)...)
+	}
+
+	var orphaned []types.Hash
+	codes := udb.NewCodeIterator()
+	defer codes.Release()
+	for codes.Next() {
+		codeHash := codes.Value().Hash
+		if _, found := referenced[codeHash]; !found && codeHash != emptyCodeHash {
+			orphaned = append(orphaned, codeHash)
+		}
+	}
+	if err = codes.Error(); err != nil {
+		return nil, fmt.Errorf("cannot iterate codes; %w", err)
+	}
+
+	if len(orphaned) > 0 {
+		// codes are shared with substates if both are stored within the same backend
+		if err = addSubstateCodeHashes(udb, referenced); err != nil {
+			return nil, err
+		}
+	}
+	for _, codeHash := range orphaned {
+		if _, found := referenced[codeHash]; !found {
+			report(OrphanedCode, 0, "code %s is not referenced", codeHash)
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Block < problems[j].Block })
+	return problems, nil
+}
+
+// addSubstateCodeHashes adds hashes of codes referenced by substates stored within db into hashes.
+// These are codes of accounts within InputSubstate and OutputSubstate and init codes of contract creations.
+func addSubstateCodeHashes(db BaseDB, hashes map[types.Hash]struct{}) error {
+	iter := db.NewIterator([]byte(SubstateDBPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		r, err := rlp.Decode(iter.Value())
+		if err != nil {
+			return fmt.Errorf("cannot decode substate %v; %w", iter.Key(), err)
+		}
+		for _, acc := range r.InputSubstate.Accounts {
+			hashes[acc.CodeHash] = struct{}{}
+		}
+		for _, acc := range r.OutputSubstate.Accounts {
+			hashes[acc.CodeHash] = struct{}{}
+		}
+		if r.Message != nil && r.Message.InitCodeHash != nil {
+			hashes[*r.Message.InitCodeHash] = struct{}{}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return fmt.Errorf("cannot iterate substates; %w", err)
+	}
+	return nil
+}
+
+// checkContinuity reports missing UpdateSets at multiples of interval between the first and the last
+// UpdateSet, and UpdateSets between multiples of interval if size is zero. The last UpdateSet may end anywhere.
+func checkContinuity(blocks []uint64, interval, size uint64) []UpdateSetProblem {
+	var problems []UpdateSetProblem
+	for i, block := range blocks {
+		if i > 0 {
+			prev := blocks[i-1]
+			for checkpoint := (prev/interval + 1) * interval; checkpoint < block; checkpoint += interval {
+				problems = append(problems, UpdateSetProblem{Kind: UpdateSetGap, Block: checkpoint, Message: "update-set is missing"})
+			}
+		}
+		if size == 0 && block%interval != 0 && i < len(blocks)-1 {
+			problems = append(problems, UpdateSetProblem{
+				Kind:    MisalignedUpdateSet,
+				Block:   block,
+				Message: fmt.Sprintf("update-set is not aligned to interval %v", interval),
+			})
+		}
+	}
+	return problems
+}
+
+// getDeletedAccountsInRange returns accounts destroyed or resurrected between from and to block (including from and to).
+func (db *DestroyedAccountDB) getDeletedAccountsInRange(from, to uint64) (map[types.Address]struct{}, error) {
+	iter := db.backend.NewIterator([]byte(DestroyedAccountPrefix), BlockToBytes(from))
+	defer iter.Release()
+
+	deleted := make(map[types.Address]struct{})
+	for iter.Next() {
+		block, _, err := DecodeDestroyedAccountKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		if block > to {
+			break
+		}
+		list, err := DecodeAddressList(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range append(list.DestroyedAccounts, list.ResurrectedAccounts...) {
+			deleted[addr] = struct{}{}
+		}
+	}
+	return deleted, iter.Error()
+}
+
+func sameAddresses(set map[types.Address]struct{}, list []types.Address) bool {
+	unique := make(map[types.Address]struct{}, len(list))
+	for _, addr := range list {
+		if _, found := set[addr]; !found {
+			return false
+		}
+		unique[addr] = struct{}{}
+	}
+	return len(unique) == len(set)
+}
+
+func validateUpdateSetsAction(ctx *cli.Context) error {
+	udb, err := NewReadOnlyUpdateDB(ctx.Path(UpdateDbFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer udb.Close()
+
+	var ddb *DestroyedAccountDB
+	if path := ctx.Path(DeletionDbFlag.Name); path != "" {
+		ddb, err = NewReadOnlyDestroyedAccountDB(path)
+		if err != nil {
+			return err
+		}
+		defer ddb.Close()
+	}
+
+	problems, err := ValidateUpdateSets(udb, ddb)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintln(ctx.App.Writer, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("found %v problems in update-sets", len(problems))
+	}
+	return nil
+}
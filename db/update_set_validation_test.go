@@ -0,0 +1,161 @@
+package db
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/updateset"
+)
+
+func TestValidateUpdateSets_ValidDB(t *testing.T) {
+	udb, ddb := createValidUpdateDB(t, t.TempDir()+"update-db")
+
+	problems, err := ValidateUpdateSets(udb, ddb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 0 {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+func TestValidateUpdateSets_ReportsProblems(t *testing.T) {
+	udb, ddb := createValidUpdateDB(t, t.TempDir()+"update-db")
+
+	if err := udb.DeleteUpdateSet(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := udb.PutCode([]byte{0xee}); err != nil {
+		t.Fatal(err)
+	}
+	ws := substate.NewWorldState().Add(types.Address{9}, 1, big.NewInt(1), []byte{0xdd})
+	if err := udb.PutUpdateSet(updateset.NewUpdateSet(ws, 3), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := udb.DeleteCode(hash.Keccak256Hash([]byte{0xdd})); err != nil {
+		t.Fatal(err)
+	}
+	if err := ddb.SetDestroyedAccounts(5, 0, []types.Address{{7}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := ValidateUpdateSets(udb, ddb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the update-set at block 3 covers destruction of account 2
+	want := []UpdateSetProblem{
+		{Kind: OrphanedCode, Block: 0},
+		{Kind: MissingCode, Block: 3},
+		{Kind: DeletedAccountsMismatch, Block: 3},
+		{Kind: MisalignedUpdateSet, Block: 3},
+		{Kind: UpdateSetGap, Block: 4},
+		{Kind: DeletedAccountsMismatch, Block: 6},
+	}
+	if len(problems) != len(want) {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+	for i, p := range problems {
+		if p.Kind != want[i].Kind || p.Block != want[i].Block {
+			t.Fatalf("unexpected problem %v\ngot: %v\nwant: %v at block %v", i, p, want[i].Kind, want[i].Block)
+		}
+	}
+}
+
+func TestValidateUpdateSets_ReportsMissingMetadata(t *testing.T) {
+	udb, err := newUpdateDB(t.TempDir()+"update-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = udb.PutUpdateSet(testUpdateSet, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := ValidateUpdateSets(udb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || problems[0].Kind != MetadataMismatch {
+		t.Fatalf("unexpected problems: %v", problems)
+	}
+}
+
+func TestValidateUpdateSets_SkipsCodeOfSubstatesInSharedBackend(t *testing.T) {
+	udb, _ := createValidUpdateDB(t, t.TempDir()+"update-db")
+	sdb := MakeDefaultSubstateDBFromBaseDB(udb)
+
+	ss := newTestSubstate(1, 0)
+	ss.InputSubstate[types.Address{8}] = substate.NewAccount(0, big.NewInt(0), []byte{0xaa})
+	ss.Message.To = nil
+	ss.Message.Data = []byte{0xbb}
+	putTestSubstates(t, sdb, ss)
+	if err := udb.PutCode([]byte{0xcc}); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := ValidateUpdateSets(udb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "orphaned code: code " + hash.Keccak256Hash([]byte{0xcc}).String() + " is not referenced"
+	if len(problems) != 1 || problems[0].String() != want {
+		t.Fatalf("unexpected problems\ngot: %v\nwant: [%v]", problems, want)
+	}
+}
+
+func TestValidateUpdateSetsCommand(t *testing.T) {
+	path := t.TempDir() + "update-db"
+	udb, ddb := createValidUpdateDB(t, path)
+	if err := udb.DeleteUpdateSet(4); err != nil {
+		t.Fatal(err)
+	}
+	udb.Close()
+	ddb.Close()
+
+	var out bytes.Buffer
+	app := &cli.App{
+		Commands: []*cli.Command{&ValidateUpdateSetsCommand},
+		Writer:   &out,
+	}
+	if err := app.Run([]string{"substate", "validate-update-sets", "--update-db", path}); err == nil {
+		t.Fatal("command must fail")
+	}
+	if !strings.Contains(out.String(), "gap: update-set is missing at block 4") {
+		t.Fatalf("unexpected output: %v", out.String())
+	}
+}
+
+// createValidUpdateDB generates update-sets at blocks 2, 4 and 6 with an account destroyed in block 3.
+func createValidUpdateDB(t *testing.T, path string) (*updateDB, *DestroyedAccountDB) {
+	sdb, err := newSubstateDB(t.TempDir()+"substate-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	udb, err := newUpdateDB(path, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ddb, err := newDestroyedAccountDB(t.TempDir()+"destroyed-db", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	putTestSubstates(t, sdb, newTestSubstate(1, 0), newTestSubstate(3, 0), newTestSubstate(5, 0))
+	if err = ddb.SetDestroyedAccounts(3, 0, []types.Address{{2}}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &UpdateSetGenerator{SubstateDB: sdb, UpdateDB: udb, DestroyedAccountDB: ddb, Interval: 2}
+	if err = g.Generate(1, 6); err != nil {
+		t.Fatal(err)
+	}
+	return udb, ddb
+}
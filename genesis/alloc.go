@@ -0,0 +1,109 @@
+// Package genesis imports and exports world states as genesis allocations and state dumps.
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hexutil"
+)
+
+// Alloc is the initial allocation of accounts within a genesis.json file.
+type Alloc map[types.Address]Account
+
+// Account is an account within an Alloc.
+type Account struct {
+	Code    hexutil.Bytes             `json:"code,omitempty"`
+	Storage map[types.Hash]types.Hash `json:"storage,omitempty"`
+	Balance *hexutil.Big              `json:"balance"`
+	Nonce   hexutil.Uint64            `json:"nonce,omitempty"`
+}
+
+// ReadAlloc reads the alloc of a genesis.json file. Both a complete genesis file
+// and a bare alloc object are accepted, other fields of the genesis are ignored.
+func ReadAlloc(r io.Reader) (Alloc, error) {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return nil, fmt.Errorf("cannot decode genesis; %w", err)
+	}
+
+	var alloc Alloc
+	if raw, found := fields["alloc"]; found {
+		if err := json.Unmarshal(raw, &alloc); err != nil {
+			return nil, fmt.Errorf("cannot decode genesis alloc; %w", err)
+		}
+		return alloc, nil
+	}
+
+	alloc = make(Alloc, len(fields))
+	for key, raw := range fields {
+		var addr types.Address
+		if err := addr.UnmarshalText([]byte(key)); err != nil {
+			return nil, fmt.Errorf("invalid address %q; %w", key, err)
+		}
+		var acc Account
+		if err := json.Unmarshal(raw, &acc); err != nil {
+			return nil, fmt.Errorf("cannot decode account %s; %w", key, err)
+		}
+		alloc[addr] = acc
+	}
+	return alloc, nil
+}
+
+// NewAlloc returns Alloc containing all accounts of ws.
+func NewAlloc(ws substate.WorldState) Alloc {
+	alloc := make(Alloc, len(ws))
+	for addr, acc := range ws {
+		account := Account{
+			Code:    acc.Code,
+			Balance: (*hexutil.Big)(balanceOf(acc)),
+			Nonce:   hexutil.Uint64(acc.Nonce),
+		}
+		for key, value := range acc.Storage {
+			if account.Storage == nil {
+				account.Storage = make(map[types.Hash]types.Hash, len(acc.Storage))
+			}
+			account.Storage[key] = value
+		}
+		alloc[addr] = account
+	}
+	return alloc
+}
+
+// ToWorldState returns the allocation as a world state.
+func (a Alloc) ToWorldState() substate.WorldState {
+	ws := substate.NewWorldState()
+	for addr, account := range a {
+		balance := new(big.Int)
+		if account.Balance != nil {
+			balance.Set(account.Balance.ToInt())
+		}
+
+		acc := substate.NewAccount(uint64(account.Nonce), balance, account.Code)
+		for key, value := range account.Storage {
+			acc.Storage[key] = value
+		}
+		ws[addr] = acc
+	}
+	return ws
+}
+
+// WriteAlloc writes ws as a genesis.json file containing only the alloc.
+func WriteAlloc(w io.Writer, ws substate.WorldState) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Alloc Alloc `json:"alloc"`
+	}{NewAlloc(ws)})
+}
+
+func balanceOf(acc *substate.Account) *big.Int {
+	if acc.Balance == nil {
+		return new(big.Int)
+	}
+	return acc.Balance
+}
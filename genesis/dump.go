@@ -0,0 +1,127 @@
+package genesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/trie"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/types/hexutil"
+)
+
+// Dump is a world state in the format produced by geth dump.
+type Dump struct {
+	Root     types.Hash                    `json:"root"`
+	Accounts map[types.Address]DumpAccount `json:"accounts"`
+}
+
+// DumpAccount is an account within a Dump.
+type DumpAccount struct {
+	Balance  string                    `json:"balance"` // decimal
+	Nonce    uint64                    `json:"nonce"`
+	Root     types.Hash                `json:"root"` // storage root
+	CodeHash types.Hash                `json:"codeHash"`
+	Code     hexutil.Bytes             `json:"code,omitempty"`
+	Storage  map[types.Hash]types.Hash `json:"storage,omitempty"`
+	Address  *types.Address            `json:"address,omitempty"` // only set in line-delimited dumps
+}
+
+// ReadDump reads a state dump. Both a single JSON object and the line-delimited form,
+// where the root is followed by one account per line, are accepted.
+func ReadDump(r io.Reader) (*Dump, error) {
+	dump := &Dump{Accounts: make(map[types.Address]DumpAccount)}
+
+	dec := json.NewDecoder(r)
+	for {
+		var line struct {
+			DumpAccount
+			Accounts map[types.Address]DumpAccount `json:"accounts"`
+		}
+		err := dec.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			return dump, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode dump; %w", err)
+		}
+
+		switch {
+		case line.Address != nil:
+			dump.Accounts[*line.Address] = line.DumpAccount
+		default:
+			// header carrying the state root, possibly with all accounts
+			dump.Root = line.Root
+			for addr, acc := range line.Accounts {
+				dump.Accounts[addr] = acc
+			}
+		}
+	}
+}
+
+// NewDump returns Dump of all accounts of ws. The root is the state root of ws.
+func NewDump(ws substate.WorldState) *Dump {
+	dump := &Dump{
+		Root:     trie.StateRoot(ws),
+		Accounts: make(map[types.Address]DumpAccount, len(ws)),
+	}
+	for addr, acc := range ws {
+		account := DumpAccount{
+			Balance:  balanceOf(acc).String(),
+			Nonce:    acc.Nonce,
+			Root:     trie.StorageRoot(acc),
+			CodeHash: acc.CodeHash(),
+			Code:     acc.Code,
+		}
+		for key, value := range acc.Storage {
+			if value == (types.Hash{}) {
+				continue
+			}
+			if account.Storage == nil {
+				account.Storage = make(map[types.Hash]types.Hash)
+			}
+			account.Storage[key] = value
+		}
+		dump.Accounts[addr] = account
+	}
+	return dump
+}
+
+// ToWorldState returns the dump as a world state.
+// Code and storage of every account are checked against the code hash and the storage root,
+// so dumps created without code or storage are rejected.
+func (d *Dump) ToWorldState() (substate.WorldState, error) {
+	ws := substate.NewWorldState()
+	for addr, account := range d.Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q of account %s", account.Balance, addr)
+		}
+
+		if codeHash := hash.Keccak256Hash(account.Code); codeHash != account.CodeHash && !(len(account.Code) == 0 && account.CodeHash == (types.Hash{})) {
+			return nil, fmt.Errorf("code of account %s does not match its hash %s", addr, account.CodeHash)
+		}
+
+		acc := substate.NewAccount(account.Nonce, balance, bytes.Clone(account.Code))
+		for key, value := range account.Storage {
+			acc.Storage[key] = value
+		}
+		if root := trie.StorageRoot(acc); root != account.Root && account.Root != (types.Hash{}) {
+			return nil, fmt.Errorf("storage of account %s does not match its root %s", addr, account.Root)
+		}
+		ws[addr] = acc
+	}
+	return ws, nil
+}
+
+// WriteDump writes ws as a state dump in a single JSON object.
+func WriteDump(w io.Writer, ws substate.WorldState) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewDump(ws))
+}
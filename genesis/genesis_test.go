@@ -0,0 +1,142 @@
+package genesis
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const testGenesis = `{
+  "config": {"chainId": 4002},
+  "alloc": {
+    "0x0100000000000000000000000000000000000000": {"balance": "1000"},
+    "0200000000000000000000000000000000000000": {
+      "balance": "0x10",
+      "nonce": "0x1",
+      "code": "0x6000",
+      "storage": {"0x01": "0x02"}
+    }
+  }
+}`
+
+func newTestWorldState() substate.WorldState {
+	ws := substate.NewWorldState().
+		Add(types.Address{1}, 0, big.NewInt(1000), nil).
+		Add(types.Address{2}, 1, big.NewInt(16), []byte{0x60, 0x00})
+	ws[types.Address{2}].Storage[types.BytesToHash([]byte{1})] = types.BytesToHash([]byte{2})
+	return ws
+}
+
+func TestReadAlloc(t *testing.T) {
+	alloc, err := ReadAlloc(strings.NewReader(testGenesis))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws := alloc.ToWorldState(); !ws.Equal(newTestWorldState()) {
+		t.Fatalf("unexpected world state\ngot: %v\nwant: %v", ws, newTestWorldState())
+	}
+
+	// bare alloc without the genesis
+	alloc, err = ReadAlloc(strings.NewReader(`{"0x01": {"balance": "1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alloc) != 1 || alloc[types.BytesToAddress([]byte{1})].Balance.ToInt().Int64() != 1 {
+		t.Fatalf("unexpected alloc: %v", alloc)
+	}
+}
+
+func TestWriteAlloc_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAlloc(&buf, newTestWorldState()); err != nil {
+		t.Fatal(err)
+	}
+
+	alloc, err := ReadAlloc(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws := alloc.ToWorldState(); !ws.Equal(newTestWorldState()) {
+		t.Fatalf("unexpected world state\ngot: %v\nwant: %v", ws, newTestWorldState())
+	}
+}
+
+func TestWriteDump_RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDump(&buf, newTestWorldState()); err != nil {
+		t.Fatal(err)
+	}
+
+	dump, err := ReadDump(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.Root != NewDump(newTestWorldState()).Root {
+		t.Fatalf("unexpected root %v", dump.Root)
+	}
+
+	ws, err := dump.ToWorldState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Equal(newTestWorldState()) {
+		t.Fatalf("unexpected world state\ngot: %v\nwant: %v", ws, newTestWorldState())
+	}
+}
+
+func TestReadDump_LineDelimited(t *testing.T) {
+	input := `{"root": "0x01"}
+{"balance": "5", "nonce": 2, "root": "0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421", "codeHash": "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", "address": "0x0100000000000000000000000000000000000000"}
+`
+	dump, err := ReadDump(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.Root != types.BytesToHash([]byte{1}) || len(dump.Accounts) != 1 {
+		t.Fatalf("unexpected dump: %+v", dump)
+	}
+
+	ws, err := dump.ToWorldState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := substate.NewWorldState().Add(types.Address{1}, 2, big.NewInt(5), nil)
+	if !ws.Equal(want) {
+		t.Fatalf("unexpected world state\ngot: %v\nwant: %v", ws, want)
+	}
+}
+
+func TestDump_ToWorldStateRejectsMissingCode(t *testing.T) {
+	dump := NewDump(newTestWorldState())
+	acc := dump.Accounts[types.Address{2}]
+	acc.Code = nil
+	dump.Accounts[types.Address{2}] = acc
+
+	if _, err := dump.ToWorldState(); err == nil {
+		t.Fatal("dump without code must be rejected")
+	}
+}
+
+func TestPutGenesis(t *testing.T) {
+	udb, err := db.NewDefaultUpdateDB(t.TempDir() + "update-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = PutGenesis(udb, newTestWorldState()); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := GetGenesis(udb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Equal(newTestWorldState()) {
+		t.Fatalf("unexpected world state\ngot: %v\nwant: %v", ws, newTestWorldState())
+	}
+}
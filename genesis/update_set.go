@@ -0,0 +1,26 @@
+package genesis
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/updateset"
+)
+
+// PutGenesis stores ws as the UpdateSet of block 0, which is applied before all other UpdateSets.
+func PutGenesis(udb db.UpdateDB, ws substate.WorldState) error {
+	if err := udb.PutUpdateSet(updateset.NewUpdateSet(ws, 0), nil); err != nil {
+		return fmt.Errorf("cannot put genesis update-set; %w", err)
+	}
+	return nil
+}
+
+// GetGenesis returns the world state stored as the UpdateSet of block 0.
+func GetGenesis(udb db.UpdateDB) (substate.WorldState, error) {
+	us, err := udb.GetUpdateSet(0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get genesis update-set; %w", err)
+	}
+	return us.WorldState, nil
+}
@@ -0,0 +1,378 @@
+// Package importer builds substates from geth traces, block headers and receipts.
+//
+// Input of a block consists of:
+//   - the block returned by eth_getBlockByNumber with full transactions,
+//   - receipts returned by eth_getBlockReceipts,
+//   - output of debug_traceBlock with the prestateTracer in diffMode,
+//   - optionally output of debug_traceBlock with the prestateTracer without diffMode.
+//
+// The diffMode trace is required to derive the post-state. Without the plain prestate
+// trace, InputSubstate only contains accounts and slots modified by the transaction.
+package importer
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// Block holds all inputs required to build substates of a block.
+type Block struct {
+	Header    *Header
+	Receipts  []*Receipt
+	Diffs     []*Trace // prestateTracer output with diffMode
+	Prestates []*Trace // prestateTracer output without diffMode, optional
+}
+
+// ReadBlockFiles reads a Block from JSON files. The prestatePath is optional and may be empty.
+func ReadBlockFiles(headerPath, receiptsPath, diffPath, prestatePath string) (*Block, error) {
+	b := new(Block)
+
+	err := readFile(headerPath, func(f *os.File) (err error) {
+		b.Header, err = ReadHeader(f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readFile(receiptsPath, func(f *os.File) (err error) {
+		b.Receipts, err = ReadReceipts(f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readFile(diffPath, func(f *os.File) (err error) {
+		b.Diffs, err = ReadTraces(f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if prestatePath == "" {
+		return b, nil
+	}
+	err = readFile(prestatePath, func(f *os.File) (err error) {
+		b.Prestates, err = ReadTraces(f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func readFile(path string, read func(f *os.File) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("cannot open %v; %w", path, err)
+	}
+	defer f.Close()
+
+	if err = read(f); err != nil {
+		return fmt.Errorf("cannot read %v; %w", path, err)
+	}
+	return nil
+}
+
+// Substates converts the block into substates ordered by transaction index.
+// The profile is used to derive the blob base fee of blocks without blob transactions,
+// it may be nil for blocks before Cancun. Receipts and traces must belong to the transactions
+// at the same index, traces without a transaction hash are not checked.
+func (b *Block) Substates(profile *chain.Profile) ([]*substate.Substate, error) {
+	txs := b.Header.Transactions
+	if len(b.Receipts) != len(txs) || len(b.Diffs) != len(txs) {
+		return nil, fmt.Errorf("block %v has %v transactions, %v receipts and %v diff traces",
+			uint64(b.Header.Number), len(txs), len(b.Receipts), len(b.Diffs))
+	}
+	if b.Prestates != nil && len(b.Prestates) != len(txs) {
+		return nil, fmt.Errorf("block %v has %v transactions and %v prestate traces", uint64(b.Header.Number), len(txs), len(b.Prestates))
+	}
+
+	substates := make([]*substate.Substate, len(txs))
+	for i, tx := range txs {
+		diff := b.Diffs[i].Diff
+		if diff == nil {
+			return nil, fmt.Errorf("trace of tx %v is not in diffMode", i)
+		}
+		if b.Receipts[i].TransactionHash != tx.Hash {
+			return nil, fmt.Errorf("receipt of tx %v belongs to transaction %v instead of %v", i, b.Receipts[i].TransactionHash, tx.Hash)
+		}
+		if h := b.Diffs[i].TxHash; h != nil && *h != tx.Hash {
+			return nil, fmt.Errorf("diff trace of tx %v belongs to transaction %v instead of %v", i, *h, tx.Hash)
+		}
+
+		input := toWorldState(diff.Pre)
+		if b.Prestates != nil {
+			if b.Prestates[i].Prestate == nil {
+				return nil, fmt.Errorf("trace of tx %v is not a prestate", i)
+			}
+			if h := b.Prestates[i].TxHash; h != nil && *h != tx.Hash {
+				return nil, fmt.Errorf("prestate trace of tx %v belongs to transaction %v instead of %v", i, *h, tx.Hash)
+			}
+			input = toWorldState(b.Prestates[i].Prestate)
+		}
+
+		// every substate owns its env so that modifying one does not affect the others
+		env, err := b.env(profile)
+		if err != nil {
+			return nil, err
+		}
+
+		substates[i] = substate.NewSubstate(
+			input,
+			postState(input, diff),
+			env,
+			newMessage(tx),
+			newResult(b.Receipts[i]),
+			uint64(b.Header.Number),
+			i,
+		)
+	}
+	return substates, nil
+}
+
+func (b *Block) env(profile *chain.Profile) (*substate.Env, error) {
+	h := b.Header
+	env := &substate.Env{
+		Coinbase:    h.Miner,
+		Difficulty:  bigOf(h.Difficulty.ToInt()),
+		GasLimit:    uint64(h.GasLimit),
+		Number:      uint64(h.Number),
+		Timestamp:   uint64(h.Timestamp),
+		BlockHashes: make(map[uint64]types.Hash),
+	}
+	if h.BaseFee != nil {
+		env.BaseFee = new(big.Int).Set(h.BaseFee.ToInt())
+	}
+	if h.ExcessBlobGas != nil {
+		excessBlobGas := uint64(*h.ExcessBlobGas)
+		env.ExcessBlobGas = &excessBlobGas
+		blobBaseFee, err := b.blobBaseFee(profile, excessBlobGas)
+		if err != nil {
+			return nil, err
+		}
+		env.BlobBaseFee = blobBaseFee
+	}
+	if h.BlobGasUsed != nil {
+		blobGasUsed := uint64(*h.BlobGasUsed)
+		env.BlobGasUsed = &blobGasUsed
+	}
+	// after the merge, mixHash holds the randomness of the beacon chain
+	if env.Difficulty.Sign() == 0 {
+		prevRandao := h.MixHash
+		env.PrevRandao = &prevRandao
+	}
+	env.ParentBeaconBlockRoot = h.ParentBeaconBlockRoot
+	if h.Withdrawals != nil {
+		env.Withdrawals = make([]*types.Withdrawal, len(h.Withdrawals))
+		for i, w := range h.Withdrawals {
+			env.Withdrawals[i] = &types.Withdrawal{
+				Index:     uint64(w.Index),
+				Validator: uint64(w.Validator),
+				Address:   w.Address,
+				Amount:    uint64(w.Amount),
+			}
+		}
+	}
+	return env, nil
+}
+
+// blobBaseFee returns blobGasPrice of receipts if any of them has it.
+// Otherwise, the blob base fee is derived from excess blob gas by the fork schedule of the profile.
+func (b *Block) blobBaseFee(profile *chain.Profile, excessBlobGas uint64) (*big.Int, error) {
+	for _, r := range b.Receipts {
+		if r.BlobGasPrice != nil {
+			return new(big.Int).Set(r.BlobGasPrice.ToInt()), nil
+		}
+	}
+	if profile == nil {
+		return nil, fmt.Errorf("blob base fee of block %v requires a chain profile", uint64(b.Header.Number))
+	}
+	blobBaseFee := profile.BlobBaseFee(uint64(b.Header.Number), excessBlobGas)
+	if blobBaseFee == nil {
+		return nil, fmt.Errorf("block %v has excess blob gas before Cancun of %v", uint64(b.Header.Number), profile.Name)
+	}
+	return blobBaseFee, nil
+}
+
+func newMessage(tx *Transaction) *substate.Message {
+	gasPrice := bigOf(tx.GasPrice.ToInt())
+	gasFeeCap, gasTipCap := gasPrice, gasPrice
+	if tx.MaxFeePerGas != nil {
+		gasFeeCap = new(big.Int).Set(tx.MaxFeePerGas.ToInt())
+	}
+	if tx.MaxPriorityFeePerGas != nil {
+		gasTipCap = new(big.Int).Set(tx.MaxPriorityFeePerGas.ToInt())
+	}
+	var blobGasFeeCap *big.Int
+	if tx.MaxFeePerBlobGas != nil {
+		blobGasFeeCap = new(big.Int).Set(tx.MaxFeePerBlobGas.ToInt())
+	}
+
+	msg := substate.NewMessage(
+		uint64(tx.Nonce),
+		true,
+		gasPrice,
+		uint64(tx.Gas),
+		tx.From,
+		tx.To,
+		bigOf(tx.Value.ToInt()),
+		tx.Input,
+		nil,
+		tx.AccessList,
+		gasFeeCap,
+		gasTipCap,
+		blobGasFeeCap,
+		tx.BlobVersionedHashes,
+	)
+	txHash := tx.Hash
+	msg.TxHash = &txHash
+	if tx.Type != nil {
+		txType := uint8(*tx.Type)
+		msg.Type = &txType
+	}
+	if tx.ChainID != nil {
+		msg.ChainID = new(big.Int).Set(tx.ChainID.ToInt())
+	}
+	return msg
+}
+
+func newResult(r *Receipt) *substate.Result {
+	logs := make([]*types.Log, len(r.Logs))
+	for i, l := range r.Logs {
+		logs[i] = &types.Log{Address: l.Address, Topics: l.Topics, Data: l.Data}
+	}
+
+	var contract types.Address
+	if r.ContractAddress != nil {
+		contract = *r.ContractAddress
+	}
+	result := substate.NewResult(uint64(r.Status), types.BytesToBloom(r.LogsBloom), logs, contract, uint64(r.GasUsed))
+	if r.Type != nil {
+		txType := uint8(*r.Type)
+		result.Type = &txType
+	}
+	if r.CumulativeGasUsed != nil {
+		cumulativeGasUsed := uint64(*r.CumulativeGasUsed)
+		result.CumulativeGasUsed = &cumulativeGasUsed
+	}
+	if r.EffectiveGasPrice != nil {
+		result.EffectiveGasPrice = new(big.Int).Set(r.EffectiveGasPrice.ToInt())
+	}
+	if r.BlobGasUsed != nil {
+		blobGasUsed := uint64(*r.BlobGasUsed)
+		result.BlobGasUsed = &blobGasUsed
+	}
+	if r.BlobGasPrice != nil {
+		result.BlobGasPrice = new(big.Int).Set(r.BlobGasPrice.ToInt())
+	}
+	return result
+}
+
+func toWorldState(p Prestate) substate.WorldState {
+	ws := substate.NewWorldState()
+	for addr, acc := range p {
+		ws[addr] = substate.NewAccount(0, new(big.Int), nil)
+		apply(ws[addr], acc)
+	}
+	return ws
+}
+
+// postState returns input modified by diff. Accounts present only within the pre-state
+// of diff were deleted, slots present only within the pre-state of diff were cleared.
+func postState(input substate.WorldState, diff *Diff) substate.WorldState {
+	post := substate.NewWorldState()
+	for addr, acc := range input {
+		post[addr] = acc.Copy()
+	}
+
+	for addr, pre := range diff.Pre {
+		postAcc, modified := diff.Post[addr]
+		if !modified {
+			delete(post, addr)
+			continue
+		}
+		acc, found := post[addr]
+		if !found {
+			acc = substate.NewAccount(0, new(big.Int), nil)
+			post[addr] = acc
+		}
+		for key := range pre.Storage {
+			if _, found = postAcc.Storage[key]; !found {
+				acc.Storage[key] = types.Hash{}
+			}
+		}
+	}
+
+	for addr, postAcc := range diff.Post {
+		acc, found := post[addr]
+		if !found {
+			acc = substate.NewAccount(0, new(big.Int), nil)
+			post[addr] = acc
+		}
+		apply(acc, postAcc)
+	}
+	return post
+}
+
+// apply overwrites acc by all fields present within t.
+func apply(acc *substate.Account, t *TraceAccount) {
+	if t.Balance != nil {
+		acc.Balance = new(big.Int).Set(t.Balance.ToInt())
+	}
+	if t.Nonce != nil {
+		acc.Nonce = uint64(*t.Nonce)
+	}
+	if t.Code != nil {
+		acc.Code = append([]byte{}, *t.Code...)
+	}
+	for key, value := range t.Storage {
+		acc.Storage[key] = value
+	}
+}
+
+func bigOf(b *big.Int) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(b)
+}
+
+// Importer writes substates of imported blocks into a SubstateDB.
+type Importer struct {
+	DB db.SubstateDB
+
+	// Profile of the imported chain, required for blocks since Cancun, see Block.Substates.
+	Profile *chain.Profile
+}
+
+// Import writes all substates of b into the DB within a single batch
+// and stores hash of the block within the block-hash table.
+func (i *Importer) Import(b *Block) error {
+	substates, err := b.Substates(i.Profile)
+	if err != nil {
+		return fmt.Errorf("cannot convert block %v; %w", uint64(b.Header.Number), err)
+	}
+	if err = i.DB.PutSubstates(substates...); err != nil {
+		return fmt.Errorf("cannot import block %v; %w", uint64(b.Header.Number), err)
+	}
+	return i.DB.PutBlockHash(uint64(b.Header.Number), b.Header.Hash)
+}
+
+// ImportFiles reads a block from JSON files by ReadBlockFiles and imports it.
+func (i *Importer) ImportFiles(headerPath, receiptsPath, diffPath, prestatePath string) error {
+	b, err := ReadBlockFiles(headerPath, receiptsPath, diffPath, prestatePath)
+	if err != nil {
+		return err
+	}
+	return i.Import(b)
+}
@@ -0,0 +1,245 @@
+package importer
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hexutil"
+)
+
+const testHeader = `{"jsonrpc":"2.0","id":1,"result":{
+	"number":"0x10","hash":"0xbb","miner":"0x0000000000000000000000000000000000000009",
+	"difficulty":"0x0","gasLimit":"0x1c9c380","timestamp":"0x64","baseFeePerGas":"0x7","excessBlobGas":"0x0","blobGasUsed":"0x20000",
+	"mixHash":"0xcc","parentBeaconBlockRoot":"0xdd",
+	"withdrawals":[{"index":"0x1","validatorIndex":"0x2","address":"0x0000000000000000000000000000000000000005","amount":"0x3"}],
+	"transactions":[
+		{"hash":"0x01","nonce":"0x1","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000002",
+		 "value":"0xa","gas":"0x5208","gasPrice":"0x8","maxFeePerGas":"0x9","maxPriorityFeePerGas":"0x1","input":"0x"},
+		{"hash":"0x02","nonce":"0x2","from":"0x0000000000000000000000000000000000000001","to":"0x0000000000000000000000000000000000000003",
+		 "value":"0x0","gas":"0x10000","gasPrice":"0x8","input":"0x1234","accessList":[]}
+	]}}`
+
+const testReceipts = `[
+	{"transactionHash":"0x01","status":"0x1","gasUsed":"0x5208","logsBloom":"0x","contractAddress":null,"logs":[],
+	 "type":"0x2","cumulativeGasUsed":"0x5208","effectiveGasPrice":"0x8"},
+	{"transactionHash":"0x02","status":"0x1","gasUsed":"0x6000","logsBloom":"0x","contractAddress":null,
+	 "logs":[{"address":"0x0000000000000000000000000000000000000003","topics":["0xaa"],"data":"0x01"}]}
+]`
+
+const testDiffs = `[
+	{"txHash":"0x01","result":{
+		"pre":{"0x0000000000000000000000000000000000000001":{"balance":"0x64","nonce":1}},
+		"post":{"0x0000000000000000000000000000000000000001":{"balance":"0x5a","nonce":2},
+		        "0x0000000000000000000000000000000000000002":{"balance":"0xa"}}}},
+	{"txHash":"0x02","result":{
+		"pre":{"0x0000000000000000000000000000000000000001":{"balance":"0x5a","nonce":2},
+		       "0x0000000000000000000000000000000000000003":{"balance":"0x0","code":"0x60","storage":{"0x01":"0x11","0x02":"0x22"}},
+		       "0x0000000000000000000000000000000000000004":{"balance":"0x1","code":"0xff"}},
+		"post":{"0x0000000000000000000000000000000000000001":{"nonce":3},
+		        "0x0000000000000000000000000000000000000003":{"storage":{"0x02":"0x33"}}}}}
+]`
+
+const testPrestates = `[
+	{"txHash":"0x01","result":{
+		"0x0000000000000000000000000000000000000001":{"balance":"0x64","nonce":1},
+		"0x0000000000000000000000000000000000000002":{"balance":"0x0"}}},
+	{"txHash":"0x02","result":{
+		"0x0000000000000000000000000000000000000001":{"balance":"0x5a","nonce":2},
+		"0x0000000000000000000000000000000000000003":{"balance":"0x0","code":"0x60","storage":{"0x01":"0x11","0x02":"0x22","0x03":"0x44"}},
+		"0x0000000000000000000000000000000000000004":{"balance":"0x1","code":"0xff"}}}
+]`
+
+// testProfile activates Cancun at the genesis and Prague at block 17.
+var testProfile, _ = chain.NewProfile("test", 1337, chain.Activation{Fork: chain.Cancun}, chain.Activation{Fork: chain.Prague, Block: 17})
+
+func readTestBlock(t *testing.T, withPrestates bool) *Block {
+	b := &Block{}
+	var err error
+	if b.Header, err = ReadHeader(strings.NewReader(testHeader)); err != nil {
+		t.Fatal(err)
+	}
+	if b.Receipts, err = ReadReceipts(strings.NewReader(testReceipts)); err != nil {
+		t.Fatal(err)
+	}
+	if b.Diffs, err = ReadTraces(strings.NewReader(testDiffs)); err != nil {
+		t.Fatal(err)
+	}
+	if withPrestates {
+		if b.Prestates, err = ReadTraces(strings.NewReader(testPrestates)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestBlock_Substates(t *testing.T) {
+	substates, err := readTestBlock(t, true).Substates(testProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(substates) != 2 {
+		t.Fatalf("unexpected number of substates %v", len(substates))
+	}
+
+	transfer := substates[0]
+	wantOutput := substate.NewWorldState().
+		Add(types.Address{19: 1}, 2, big.NewInt(90), nil).
+		Add(types.Address{19: 2}, 0, big.NewInt(10), nil)
+	if !transfer.OutputSubstate.Equal(wantOutput) {
+		t.Fatalf("unexpected output\ngot: %v\nwant: %v", transfer.OutputSubstate, wantOutput)
+	}
+	if transfer.Env.Number != 16 || transfer.Env.BaseFee.Int64() != 7 || transfer.Env.BlobBaseFee.Int64() != 1 {
+		t.Fatalf("unexpected env %v", transfer.Env)
+	}
+	if env := transfer.Env; *env.PrevRandao != (types.Hash{31: 0xcc}) || *env.BlobGasUsed != 0x20000 || len(env.Withdrawals) != 1 {
+		t.Fatalf("unexpected post-merge env %v", env)
+	}
+	if r := transfer.Result; *r.Type != 2 || *r.CumulativeGasUsed != 21_000 || r.EffectiveGasPrice.Int64() != 8 || r.BlobGasUsed != nil {
+		t.Fatalf("unexpected receipt fields %v", r)
+	}
+	msg := transfer.Message
+	if msg.GasFeeCap.Int64() != 9 || msg.GasTipCap.Int64() != 1 || msg.GasPrice.Int64() != 8 || *msg.TxHash != (types.Hash{31: 1}) {
+		t.Fatalf("unexpected message %v", msg)
+	}
+
+	call := substates[1]
+	if got := len(call.InputSubstate[types.Address{19: 3}].Storage); got != 3 {
+		t.Fatalf("input must contain all accessed slots, got %v", got)
+	}
+	contract := call.OutputSubstate[types.Address{19: 3}]
+	want := map[types.Hash]types.Hash{{31: 1}: {}, {31: 2}: {31: 0x33}, {31: 3}: {31: 0x44}}
+	for key, value := range want {
+		if contract.Storage[key] != value {
+			t.Fatalf("unexpected value of slot %v\ngot: %v\nwant: %v", key, contract.Storage[key], value)
+		}
+	}
+	if _, found := call.OutputSubstate[types.Address{19: 4}]; found {
+		t.Fatal("deleted account must not be in output")
+	}
+	if call.OutputSubstate[types.Address{19: 1}].Balance.Int64() != 90 {
+		t.Fatal("unchanged balance must be kept")
+	}
+	if len(call.Result.Logs) != 1 || call.Result.GasUsed != 0x6000 {
+		t.Fatalf("unexpected result %v", call.Result)
+	}
+}
+
+func TestBlock_SubstatesWithoutPrestates(t *testing.T) {
+	substates, err := readTestBlock(t, false).Substates(testProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := substates[0].InputSubstate[types.Address{19: 2}]; found {
+		t.Fatal("input must contain only modified accounts")
+	}
+}
+
+func TestBlock_SubstatesBlobBaseFee(t *testing.T) {
+	b := readTestBlock(t, false)
+	excessBlobGas := hexutil.Uint64(10_000_000)
+	b.Header.ExcessBlobGas = &excessBlobGas
+
+	// derived by the fork schedule
+	for block, want := range map[uint64]int64{16: 19, 17: 7} {
+		b.Header.Number = hexutil.Uint64(block)
+		substates, err := b.Substates(testProfile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := substates[0].Env.BlobBaseFee; got.Int64() != want {
+			t.Fatalf("block %v: unexpected blob base fee\ngot: %v\nwant: %v", block, got, want)
+		}
+	}
+
+	if _, err := b.Substates(nil); err == nil {
+		t.Fatal("blob base fee without profile must be rejected")
+	}
+
+	// taken from receipts of blob transactions
+	b.Receipts[1].BlobGasPrice = (*hexutil.Big)(big.NewInt(42))
+	substates, err := b.Substates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := substates[0].Env.BlobBaseFee; got.Int64() != 42 {
+		t.Fatalf("unexpected blob base fee\ngot: %v\nwant: 42", got)
+	}
+}
+
+func TestBlock_SubstatesRejectsMismatchedReceipts(t *testing.T) {
+	b := readTestBlock(t, false)
+	b.Receipts[0], b.Receipts[1] = b.Receipts[1], b.Receipts[0]
+	if _, err := b.Substates(testProfile); err == nil {
+		t.Fatal("mismatched receipts must be rejected")
+	}
+}
+
+func TestBlock_SubstatesRejectsMismatchedTraces(t *testing.T) {
+	for _, prestates := range []bool{false, true} {
+		b := readTestBlock(t, true)
+		traces := b.Diffs
+		if prestates {
+			traces = b.Prestates
+		}
+		traces[0], traces[1] = traces[1], traces[0]
+		if _, err := b.Substates(testProfile); err == nil || !strings.Contains(err.Error(), "belongs to transaction") {
+			t.Fatalf("mismatched traces must be rejected, got %v", err)
+		}
+	}
+}
+
+func TestBlock_SubstatesHaveOwnEnv(t *testing.T) {
+	substates, err := readTestBlock(t, true).Substates(testProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	substates[0].Env.BlockHashes[15] = types.Hash{1}
+	substates[0].Env.BaseFee.SetInt64(0)
+	if env := substates[1].Env; len(env.BlockHashes) != 0 || env.BaseFee.Int64() != 7 {
+		t.Fatalf("env of substates must not be shared %v", env)
+	}
+}
+
+func TestImporter_ImportFiles(t *testing.T) {
+	dir := t.TempDir()
+	paths := make(map[string]string)
+	for name, content := range map[string]string{"header": testHeader, "receipts": testReceipts, "diff": testDiffs, "prestate": testPrestates} {
+		paths[name] = filepath.Join(dir, name+".json")
+		if err := os.WriteFile(paths[name], []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sdb, err := db.NewDefaultSubstateDB(filepath.Join(dir, "substate-db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdb.Close()
+
+	i := &Importer{DB: sdb, Profile: testProfile}
+	if err = i.ImportFiles(paths["header"], paths["receipts"], paths["diff"], paths["prestate"]); err != nil {
+		t.Fatal(err)
+	}
+
+	ss, err := sdb.GetSubstate(16, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := ss.InputSubstate[types.Address{19: 3}].Code; len(code) != 1 || code[0] != 0x60 {
+		t.Fatalf("unexpected code %x", code)
+	}
+	hash, err := sdb.GetBlockHash(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != (types.Hash{31: 0xbb}) {
+		t.Fatalf("unexpected block hash %v", hash)
+	}
+}
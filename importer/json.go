@@ -0,0 +1,191 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hexutil"
+)
+
+// Header is a block returned by eth_getBlockByNumber with full transaction objects.
+type Header struct {
+	Number        hexutil.Uint64  `json:"number"`
+	Hash          types.Hash      `json:"hash"`
+	Miner         types.Address   `json:"miner"`
+	Difficulty    *hexutil.Big    `json:"difficulty"`
+	GasLimit      hexutil.Uint64  `json:"gasLimit"`
+	Timestamp     hexutil.Uint64  `json:"timestamp"`
+	BaseFee       *hexutil.Big    `json:"baseFeePerGas"`
+	ExcessBlobGas *hexutil.Uint64 `json:"excessBlobGas"`
+	BlobGasUsed   *hexutil.Uint64 `json:"blobGasUsed"`
+	MixHash       types.Hash      `json:"mixHash"`
+
+	ParentBeaconBlockRoot *types.Hash    `json:"parentBeaconBlockRoot"`
+	Withdrawals           []*Withdrawal  `json:"withdrawals"`
+	Transactions          []*Transaction `json:"transactions"`
+}
+
+// Withdrawal is a withdrawal within a Header.
+type Withdrawal struct {
+	Index     hexutil.Uint64 `json:"index"`
+	Validator hexutil.Uint64 `json:"validatorIndex"`
+	Address   types.Address  `json:"address"`
+	Amount    hexutil.Uint64 `json:"amount"`
+}
+
+// Transaction is a transaction object within a Header.
+type Transaction struct {
+	Hash                 types.Hash       `json:"hash"`
+	Nonce                hexutil.Uint64   `json:"nonce"`
+	From                 types.Address    `json:"from"`
+	To                   *types.Address   `json:"to"`
+	Value                *hexutil.Big     `json:"value"`
+	Gas                  hexutil.Uint64   `json:"gas"`
+	GasPrice             *hexutil.Big     `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	MaxFeePerBlobGas     *hexutil.Big     `json:"maxFeePerBlobGas"`
+	Input                hexutil.Bytes    `json:"input"`
+	AccessList           types.AccessList `json:"accessList"`
+	BlobVersionedHashes  []types.Hash     `json:"blobVersionedHashes"`
+	Type                 *hexutil.Uint64  `json:"type"`
+	ChainID              *hexutil.Big     `json:"chainId"`
+}
+
+// Receipt is a receipt returned by eth_getBlockReceipts or eth_getTransactionReceipt.
+type Receipt struct {
+	TransactionHash types.Hash     `json:"transactionHash"`
+	Status          hexutil.Uint64 `json:"status"`
+	GasUsed         hexutil.Uint64 `json:"gasUsed"`
+	LogsBloom       hexutil.Bytes  `json:"logsBloom"`
+	ContractAddress *types.Address `json:"contractAddress"`
+	Logs            []*Log         `json:"logs"`
+
+	Type              *hexutil.Uint64 `json:"type"`
+	CumulativeGasUsed *hexutil.Uint64 `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice"`
+	BlobGasUsed       *hexutil.Uint64 `json:"blobGasUsed"`
+	BlobGasPrice      *hexutil.Big    `json:"blobGasPrice"`
+}
+
+// Log is a log within a Receipt.
+type Log struct {
+	Address types.Address `json:"address"`
+	Topics  []types.Hash  `json:"topics"`
+	Data    hexutil.Bytes `json:"data"`
+}
+
+// TraceAccount is an account within output of the prestateTracer.
+// Fields missing within the post-state of diffMode are left nil.
+type TraceAccount struct {
+	Balance *hexutil.Big              `json:"balance"`
+	Nonce   *hexutil.Uint64           `json:"nonce"`
+	Code    *hexutil.Bytes            `json:"code"`
+	Storage map[types.Hash]types.Hash `json:"storage"`
+}
+
+// Prestate is the output of the prestateTracer.
+type Prestate map[types.Address]*TraceAccount
+
+// Diff is the output of the prestateTracer with diffMode enabled.
+type Diff struct {
+	Pre  Prestate `json:"pre"`
+	Post Prestate `json:"post"`
+}
+
+// Trace is the trace of a single transaction within debug_traceBlock output.
+// Exactly one of Prestate and Diff is set.
+type Trace struct {
+	TxHash   *types.Hash
+	Prestate Prestate
+	Diff     *Diff
+}
+
+// ReadHeader reads a Header from r. A JSON-RPC response wrapping the block is accepted as well.
+func ReadHeader(r io.Reader) (*Header, error) {
+	var header Header
+	if err := readJSON(r, &header); err != nil {
+		return nil, fmt.Errorf("cannot decode header; %w", err)
+	}
+	return &header, nil
+}
+
+// ReadReceipts reads receipts of a block from r. A JSON-RPC response wrapping the receipts is accepted as well.
+func ReadReceipts(r io.Reader) ([]*Receipt, error) {
+	var receipts []*Receipt
+	if err := readJSON(r, &receipts); err != nil {
+		return nil, fmt.Errorf("cannot decode receipts; %w", err)
+	}
+	return receipts, nil
+}
+
+// ReadTraces reads output of debug_traceBlock with the prestateTracer from r, both with and without diffMode.
+// A JSON-RPC response wrapping the traces is accepted as well.
+func ReadTraces(r io.Reader) ([]*Trace, error) {
+	var raw []struct {
+		TxHash *types.Hash     `json:"txHash"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := readJSON(r, &raw); err != nil {
+		return nil, fmt.Errorf("cannot decode traces; %w", err)
+	}
+
+	traces := make([]*Trace, len(raw))
+	for i, t := range raw {
+		trace, err := decodeTrace(t.Result)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode trace of tx %v; %w", i, err)
+		}
+		trace.TxHash = t.TxHash
+		traces[i] = trace
+	}
+	return traces, nil
+}
+
+func decodeTrace(data json.RawMessage) (*Trace, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	_, hasPre := fields["pre"]
+	_, hasPost := fields["post"]
+	if hasPre || hasPost {
+		var diff Diff
+		if err := json.Unmarshal(data, &diff); err != nil {
+			return nil, err
+		}
+		return &Trace{Diff: &diff}, nil
+	}
+
+	var prestate Prestate
+	if err := json.Unmarshal(data, &prestate); err != nil {
+		return nil, err
+	}
+	return &Trace{Prestate: prestate}, nil
+}
+
+// readJSON decodes r into v. If r contains a JSON-RPC response, its result is decoded.
+func readJSON(r io.Reader, v any) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &response) == nil && response.JSONRPC != "" {
+		if response.Error != nil {
+			return fmt.Errorf("json-rpc error: %v", response.Error.Message)
+		}
+		data = response.Result
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,264 @@
+// Package recorder builds substates from state accesses reported by a client.
+//
+// A client hooks a Recorder into its state layer: reads are reported by OnGet* callbacks,
+// writes by OnSet* callbacks. Reads observed before the transaction modifies a value form
+// the InputSubstate, final values of all touched accounts form the OutputSubstate.
+package recorder
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// StateReader provides values of accounts before the recorded transaction.
+// It is used to complete pre-state of accounts whose nonce, balance or code was not read.
+type StateReader interface {
+	Exist(addr types.Address) bool
+	GetNonce(addr types.Address) uint64
+	GetBalance(addr types.Address) *big.Int
+	GetCode(addr types.Address) []byte
+}
+
+// field is a bit set of account fields.
+type field uint8
+
+const (
+	nonceField field = 1 << iota
+	balanceField
+	codeField
+)
+
+// record holds accesses to a single account within the transaction.
+type record struct {
+	pre      *substate.Account // values observed before the transaction changed them
+	observed field             // fields of pre observed by reads
+
+	post    *substate.Account // values written by the transaction
+	written field             // fields of post written by the transaction
+
+	missing   bool // account did not exist before the transaction
+	destroyed bool // account does not exist after the transaction
+}
+
+func newRecord() *record {
+	return &record{
+		pre:  substate.NewAccount(0, new(big.Int), nil),
+		post: substate.NewAccount(0, new(big.Int), nil),
+	}
+}
+
+// Recorder accumulates accesses of a transaction into a substate.
+// Recorded substates are buffered until Commit writes them into the DB.
+// Recorder is not safe for concurrent use.
+type Recorder struct {
+	db      db.SubstateDB
+	pending []*substate.Substate
+
+	// current transaction
+	block   uint64
+	tx      int
+	env     *substate.Env
+	message *substate.Message
+	reader  StateReader
+	records map[types.Address]*record
+}
+
+// New returns Recorder committing into given DB.
+func New(db db.SubstateDB) *Recorder {
+	return &Recorder{db: db}
+}
+
+// BeginTransaction starts recording of given transaction. Reader is optional, without it
+// unobserved pre-state values of touched accounts are recorded as zero.
+func (r *Recorder) BeginTransaction(block uint64, tx int, env *substate.Env, message *substate.Message, reader StateReader) {
+	r.block = block
+	r.tx = tx
+	r.env = env
+	r.message = message
+	r.reader = reader
+	r.records = make(map[types.Address]*record)
+}
+
+// OnGetExist records whether addr existed before it was modified by the transaction.
+func (r *Recorder) OnGetExist(addr types.Address, exists bool) {
+	rec := r.get(addr)
+	if rec.written == 0 && !rec.destroyed {
+		rec.missing = !exists
+	}
+}
+
+// OnGetNonce records nonce read from addr.
+func (r *Recorder) OnGetNonce(addr types.Address, nonce uint64) {
+	if rec := r.observe(addr, nonceField); rec != nil {
+		rec.pre.Nonce = nonce
+	}
+}
+
+// OnGetBalance records balance read from addr.
+func (r *Recorder) OnGetBalance(addr types.Address, balance *big.Int) {
+	if rec := r.observe(addr, balanceField); rec != nil {
+		rec.pre.Balance = new(big.Int).Set(balance)
+	}
+}
+
+// OnGetCode records code read from addr.
+func (r *Recorder) OnGetCode(addr types.Address, code []byte) {
+	if rec := r.observe(addr, codeField); rec != nil {
+		rec.pre.Code = append([]byte{}, code...)
+	}
+}
+
+// OnGetState records value of storage key read from addr.
+func (r *Recorder) OnGetState(addr types.Address, key types.Hash, value types.Hash) {
+	rec := r.get(addr)
+	if rec.destroyed {
+		return
+	}
+	if _, written := rec.post.Storage[key]; written {
+		return
+	}
+	if _, found := rec.pre.Storage[key]; !found {
+		rec.pre.Storage[key] = value
+	}
+}
+
+// OnSetNonce records nonce written into addr.
+func (r *Recorder) OnSetNonce(addr types.Address, nonce uint64) {
+	rec := r.write(addr, nonceField)
+	rec.post.Nonce = nonce
+}
+
+// OnSetBalance records balance written into addr.
+func (r *Recorder) OnSetBalance(addr types.Address, balance *big.Int) {
+	rec := r.write(addr, balanceField)
+	rec.post.Balance = new(big.Int).Set(balance)
+}
+
+// OnSetCode records code written into addr.
+func (r *Recorder) OnSetCode(addr types.Address, code []byte) {
+	rec := r.write(addr, codeField)
+	rec.post.Code = append([]byte{}, code...)
+}
+
+// OnSetState records value written into storage key of addr.
+func (r *Recorder) OnSetState(addr types.Address, key types.Hash, value types.Hash) {
+	rec := r.write(addr, 0)
+	rec.post.Storage[key] = value
+}
+
+// OnSelfDestruct records that addr does not exist after the transaction.
+func (r *Recorder) OnSelfDestruct(addr types.Address) {
+	rec := r.get(addr)
+	rec.destroyed = true
+}
+
+// EndTransaction completes the current substate with given result and buffers it until Commit.
+func (r *Recorder) EndTransaction(result *substate.Result) (*substate.Substate, error) {
+	if r.records == nil {
+		return nil, fmt.Errorf("no transaction is being recorded")
+	}
+
+	input := substate.NewWorldState()
+	output := substate.NewWorldState()
+	for addr, rec := range r.records {
+		if !rec.missing && r.reader != nil && rec.observed != nonceField|balanceField|codeField {
+			rec.missing = !r.reader.Exist(addr)
+		}
+		if !rec.missing {
+			r.complete(addr, rec)
+			input[addr] = rec.pre
+		}
+		if !rec.destroyed && (!rec.missing || rec.written != 0 || len(rec.post.Storage) > 0) {
+			output[addr] = postAccount(rec)
+		}
+	}
+
+	ss := substate.NewSubstate(input, output, r.env, r.message, result, r.block, r.tx)
+	r.pending = append(r.pending, ss)
+	r.records = nil
+	return ss, nil
+}
+
+// Commit writes all buffered substates into the DB within a single batch.
+func (r *Recorder) Commit() error {
+	if len(r.pending) == 0 {
+		return nil
+	}
+	if err := r.db.PutSubstates(r.pending...); err != nil {
+		return fmt.Errorf("cannot commit %v substates; %w", len(r.pending), err)
+	}
+	r.pending = nil
+	return nil
+}
+
+// Pending returns number of substates waiting for Commit.
+func (r *Recorder) Pending() int {
+	return len(r.pending)
+}
+
+func (r *Recorder) get(addr types.Address) *record {
+	rec, found := r.records[addr]
+	if !found {
+		rec = newRecord()
+		r.records[addr] = rec
+	}
+	return rec
+}
+
+// observe returns record of addr if value f read from it belongs to pre-state, otherwise nil.
+func (r *Recorder) observe(addr types.Address, f field) *record {
+	rec := r.get(addr)
+	if rec.destroyed || rec.observed&f != 0 || rec.written&f != 0 {
+		return nil
+	}
+	rec.observed |= f
+	return rec
+}
+
+func (r *Recorder) write(addr types.Address, f field) *record {
+	rec := r.get(addr)
+	rec.written |= f
+	return rec
+}
+
+// complete fills pre-state values which were not observed from the reader.
+func (r *Recorder) complete(addr types.Address, rec *record) {
+	if r.reader == nil {
+		return
+	}
+	if rec.observed&nonceField == 0 {
+		rec.pre.Nonce = r.reader.GetNonce(addr)
+	}
+	if rec.observed&balanceField == 0 {
+		rec.pre.Balance = new(big.Int).Set(r.reader.GetBalance(addr))
+	}
+	if rec.observed&codeField == 0 {
+		rec.pre.Code = append([]byte{}, r.reader.GetCode(addr)...)
+	}
+	rec.observed = nonceField | balanceField | codeField
+}
+
+// postAccount returns account after the transaction, unwritten values are taken from the pre-state.
+func postAccount(rec *record) *substate.Account {
+	acc := rec.pre.Copy()
+	if rec.missing {
+		acc = substate.NewAccount(0, new(big.Int), nil)
+	}
+	if rec.written&nonceField != 0 {
+		acc.Nonce = rec.post.Nonce
+	}
+	if rec.written&balanceField != 0 {
+		acc.Balance = new(big.Int).Set(rec.post.Balance)
+	}
+	if rec.written&codeField != 0 {
+		acc.Code = rec.post.Code
+	}
+	for key, value := range rec.post.Storage {
+		acc.Storage[key] = value
+	}
+	return acc
+}
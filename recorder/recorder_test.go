@@ -0,0 +1,167 @@
+package recorder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+var (
+	sender    = types.Address{1}
+	recipient = types.Address{2}
+	contract  = types.Address{3}
+)
+
+type testReader substate.WorldState
+
+func (r testReader) Exist(addr types.Address) bool {
+	_, found := r[addr]
+	return found
+}
+
+func (r testReader) GetNonce(addr types.Address) uint64 {
+	if acc, found := r[addr]; found {
+		return acc.Nonce
+	}
+	return 0
+}
+
+func (r testReader) GetBalance(addr types.Address) *big.Int {
+	if acc, found := r[addr]; found {
+		return acc.Balance
+	}
+	return new(big.Int)
+}
+
+func (r testReader) GetCode(addr types.Address) []byte {
+	if acc, found := r[addr]; found {
+		return acc.Code
+	}
+	return nil
+}
+
+func newTestEnv(block uint64) (*substate.Env, *substate.Message) {
+	env := &substate.Env{Coinbase: types.Address{9}, Difficulty: big.NewInt(1), GasLimit: 1_000_000, Number: block, Timestamp: block, BlockHashes: map[uint64]types.Hash{}}
+	msg := substate.NewMessage(1, true, big.NewInt(1), 21_000, sender, &recipient, big.NewInt(10), nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(0), nil)
+	return env, msg
+}
+
+func TestRecorder_RecordsPreAndPostState(t *testing.T) {
+	pre := substate.NewWorldState().
+		Add(sender, 1, big.NewInt(100), nil).
+		Add(contract, 1, big.NewInt(0), []byte{0x60})
+	pre[contract].Storage[types.Hash{1}] = types.Hash{0x11}
+
+	r := New(nil)
+	env, msg := newTestEnv(5)
+	r.BeginTransaction(5, 0, env, msg, testReader(pre))
+
+	r.OnGetBalance(sender, big.NewInt(100))
+	r.OnSetBalance(sender, big.NewInt(90))
+	r.OnSetNonce(sender, 2)
+	// read after write must not change the pre-state
+	r.OnGetBalance(sender, big.NewInt(90))
+
+	r.OnGetExist(recipient, false)
+	r.OnSetBalance(recipient, big.NewInt(10))
+
+	r.OnGetState(contract, types.Hash{1}, types.Hash{0x11})
+	r.OnSetState(contract, types.Hash{1}, types.Hash{0x22})
+	r.OnGetState(contract, types.Hash{1}, types.Hash{0x22})
+
+	ss, err := r.EndTransaction(substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ss.InputSubstate.Equal(pre) {
+		t.Fatalf("unexpected input substate\ngot: %v\nwant: %v", ss.InputSubstate, pre)
+	}
+
+	post := substate.NewWorldState().
+		Add(sender, 2, big.NewInt(90), nil).
+		Add(recipient, 0, big.NewInt(10), nil).
+		Add(contract, 1, big.NewInt(0), []byte{0x60})
+	post[contract].Storage[types.Hash{1}] = types.Hash{0x22}
+	if !ss.OutputSubstate.Equal(post) {
+		t.Fatalf("unexpected output substate\ngot: %v\nwant: %v", ss.OutputSubstate, post)
+	}
+}
+
+func TestRecorder_SelfDestructedAccountIsNotInOutput(t *testing.T) {
+	r := New(nil)
+	env, msg := newTestEnv(5)
+	r.BeginTransaction(5, 0, env, msg, nil)
+
+	r.OnGetNonce(contract, 1)
+	r.OnGetBalance(contract, big.NewInt(5))
+	r.OnGetCode(contract, []byte{0xff})
+	r.OnSelfDestruct(contract)
+
+	ss, err := r.EndTransaction(substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := ss.InputSubstate[contract]; !found {
+		t.Fatal("destroyed account must be in input substate")
+	}
+	if _, found := ss.OutputSubstate[contract]; found {
+		t.Fatal("destroyed account must not be in output substate")
+	}
+}
+
+func TestRecorder_Commit(t *testing.T) {
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir() + "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sdb.Close()
+
+	r := New(sdb)
+	var want []*substate.Substate
+	for tx := 0; tx < 3; tx++ {
+		env, msg := newTestEnv(7)
+		r.BeginTransaction(7, tx, env, msg, nil)
+		r.OnGetCode(contract, []byte{byte(tx)})
+		r.OnSetNonce(sender, uint64(tx))
+
+		ss, err := r.EndTransaction(substate.NewResult(1, types.Bloom{}, []*types.Log{}, types.Address{}, 21_000))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, ss)
+	}
+	if r.Pending() != 3 {
+		t.Fatalf("unexpected number of pending substates: %v", r.Pending())
+	}
+
+	if err = r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Pending() != 0 {
+		t.Fatalf("pending substates were not released: %v", r.Pending())
+	}
+
+	for _, ss := range want {
+		got, err := sdb.GetSubstate(ss.Block, ss.Transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = got.Equal(ss); err != nil {
+			t.Fatalf("unexpected substate; %v", err)
+		}
+		if _, err = sdb.GetCode(hash.Keccak256Hash(ss.InputSubstate[contract].Code)); err != nil {
+			t.Fatalf("code was not committed; %v", err)
+		}
+	}
+}
+
+func TestRecorder_EndWithoutBegin(t *testing.T) {
+	if _, err := New(nil).EndTransaction(nil); err == nil {
+		t.Fatal("ending a transaction which was not begun must fail")
+	}
+}
@@ -0,0 +1,36 @@
+package replay
+
+import (
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+)
+
+// Executor executes a recorded transaction over its pre-state.
+// Implementations must not modify pre, they may be called concurrently from multiple workers.
+type Executor interface {
+	// Execute runs message within env over pre and returns resulting post-state and result.
+	Execute(block uint64, tx int, env *substate.Env, message *substate.Message, pre substate.WorldState) (substate.WorldState, *substate.Result, error)
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as Executor.
+type ExecutorFunc func(block uint64, tx int, env *substate.Env, message *substate.Message, pre substate.WorldState) (substate.WorldState, *substate.Result, error)
+
+// Execute calls f(block, tx, env, message, pre).
+func (f ExecutorFunc) Execute(block uint64, tx int, env *substate.Env, message *substate.Message, pre substate.WorldState) (substate.WorldState, *substate.Result, error) {
+	return f(block, tx, env, message, pre)
+}
+
+// EchoExecutor is a fake Executor which returns recorded output of the transaction from DB.
+// It allows testing of the replay harness without an EVM.
+type EchoExecutor struct {
+	DB db.SubstateDB
+}
+
+// Execute returns OutputSubstate and Result recorded for given block and tx.
+func (e EchoExecutor) Execute(block uint64, tx int, _ *substate.Env, _ *substate.Message, _ substate.WorldState) (substate.WorldState, *substate.Result, error) {
+	ss, err := e.DB.GetSubstate(block, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ss.OutputSubstate, ss.Result, nil
+}
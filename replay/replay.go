@@ -0,0 +1,159 @@
+// Package replay executes recorded substates and validates the outcome against the recording.
+package replay
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// Tolerance configures which differences between replayed and recorded outcome are ignored.
+type Tolerance struct {
+	// IgnoreGas excludes Result.GasUsed, Result.CumulativeGasUsed and Result.BlobGasUsed from the comparison.
+	IgnoreGas bool
+	// StatusAndLogsOnly compares only Result.Status and Result.Logs, post-state is still compared.
+	StatusAndLogsOnly bool
+	// IgnorePostState excludes the post-state from the comparison.
+	IgnorePostState bool
+	// IgnoreZeroStorage treats zero-valued storage slots as non-existing.
+	IgnoreZeroStorage bool
+}
+
+// Mismatch is a transaction whose replayed outcome differs from the recording.
+type Mismatch struct {
+	Block       uint64
+	Transaction int
+	Err         error
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%v_%v: %v", m.Block, m.Transaction, m.Err)
+}
+
+// Replayer executes all substates between First and Last block by Executor
+// and validates resulting post-state and result against the recorded ones.
+type Replayer struct {
+	DB       db.SubstateDB
+	Executor Executor
+
+	First uint64
+	Last  uint64 // inclusive
+
+	Workers   int
+	Tolerance Tolerance
+
+	// FailFast stops the replay with an error on the first mismatch.
+	FailFast bool
+}
+
+// Run replays the block range. Mismatches are returned ordered by block and transaction number.
+// Errors of the Executor abort the replay.
+func (r *Replayer) Run() ([]Mismatch, error) {
+	var (
+		mu         sync.Mutex
+		mismatches []Mismatch
+	)
+
+	pool := &db.SubstateTaskPool{
+		Name: "replay",
+		TaskFunc: func(block uint64, tx int, ss *substate.Substate, _ *db.SubstateTaskPool) error {
+			post, result, err := r.Executor.Execute(block, tx, ss.Env, ss.Message, ss.InputSubstate)
+			if err != nil {
+				return fmt.Errorf("cannot execute tx %v; %w", tx, err)
+			}
+
+			err = Validate(ss, post, result, r.Tolerance)
+			if err == nil {
+				return nil
+			}
+			if r.FailFast {
+				return fmt.Errorf("tx %v: %w", tx, err)
+			}
+
+			mu.Lock()
+			mismatches = append(mismatches, Mismatch{Block: block, Transaction: tx, Err: err})
+			mu.Unlock()
+			return nil
+		},
+
+		First: r.First,
+		Last:  r.Last,
+
+		Workers: max(r.Workers, 1),
+		DB:      r.DB,
+	}
+
+	if err := pool.Execute(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		if mismatches[i].Block != mismatches[j].Block {
+			return mismatches[i].Block < mismatches[j].Block
+		}
+		return mismatches[i].Transaction < mismatches[j].Transaction
+	})
+	return mismatches, nil
+}
+
+// Validate compares post-state and result of a replayed transaction with recorded ss.
+func Validate(ss *substate.Substate, post substate.WorldState, result *substate.Result, tol Tolerance) error {
+	if !tol.IgnorePostState {
+		want, got := ss.OutputSubstate, post
+		if tol.IgnoreZeroStorage {
+			want, got = withoutZeroStorage(want), withoutZeroStorage(got)
+		}
+		if !want.Equal(got) {
+			return fmt.Errorf("inconsistent output\nmissing or different: %v\nunexpected: %v", want.Diff(got), got.Diff(want))
+		}
+	}
+
+	want, got := normalizeResult(ss.Result, tol), normalizeResult(result, tol)
+	if !want.Equal(got) {
+		return fmt.Errorf("inconsistent result\nwant: %v\ngot: %v", want, got)
+	}
+	return nil
+}
+
+// normalizeResult returns a copy of r without fields ignored by tol.
+func normalizeResult(r *substate.Result, tol Tolerance) *substate.Result {
+	if r == nil {
+		return nil
+	}
+
+	n := *r
+	if tol.IgnoreGas {
+		n.GasUsed = 0
+		n.CumulativeGasUsed = nil
+		n.BlobGasUsed = nil
+	}
+	if tol.StatusAndLogsOnly {
+		n.GasUsed = 0
+		n.Bloom = types.Bloom{}
+		n.ContractAddress = types.Address{}
+		n.Type = nil
+		n.CumulativeGasUsed = nil
+		n.EffectiveGasPrice = nil
+		n.BlobGasUsed = nil
+		n.BlobGasPrice = nil
+	}
+	return &n
+}
+
+func withoutZeroStorage(ws substate.WorldState) substate.WorldState {
+	n := substate.NewWorldState()
+	for addr, acc := range ws {
+		cp := substate.NewAccount(acc.Nonce, acc.Balance, acc.Code)
+		for key, value := range acc.Storage {
+			if value != (types.Hash{}) {
+				cp.Storage[key] = value
+			}
+		}
+		n[addr] = cp
+	}
+	return n
+}
@@ -0,0 +1,170 @@
+package replay
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/statedb"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// newTransferSubstate returns substate of a plain transfer of 10 from Address{1} to Address{2}.
+func newTransferSubstate(block uint64, tx int) *substate.Substate {
+	to := types.Address{2}
+	return &substate.Substate{
+		InputSubstate: substate.WorldState{
+			types.Address{1}: substate.NewAccount(1, big.NewInt(100), nil),
+		},
+		OutputSubstate: substate.WorldState{
+			types.Address{1}: substate.NewAccount(2, big.NewInt(90), nil),
+			types.Address{2}: substate.NewAccount(0, big.NewInt(10), nil),
+		},
+		Env: &substate.Env{
+			Coinbase:   types.Address{3},
+			Difficulty: big.NewInt(1),
+			GasLimit:   1_000_000,
+			Number:     block,
+			Timestamp:  block,
+			BaseFee:    big.NewInt(1),
+		},
+		Message:     substate.NewMessage(1, true, big.NewInt(1), 21_000, types.Address{1}, &to, big.NewInt(10), nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(0), nil),
+		Result:      substate.NewResult(1, types.Bloom{}, []*types.Log{}, types.Address{}, 21_000),
+		Block:       block,
+		Transaction: tx,
+	}
+}
+
+func newTestDB(t *testing.T) db.SubstateDB {
+	sdb, err := db.NewDefaultSubstateDB(t.TempDir() + "test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sdb.Close() })
+
+	if err = sdb.PutSubstates(newTransferSubstate(1, 0), newTransferSubstate(1, 1), newTransferSubstate(2, 0)); err != nil {
+		t.Fatal(err)
+	}
+	return sdb
+}
+
+// transfer executes a value transfer over statedb without charging gas.
+func transfer(_ uint64, _ int, _ *substate.Env, msg *substate.Message, pre substate.WorldState) (substate.WorldState, *substate.Result, error) {
+	s := statedb.New(pre)
+	s.SetNonce(msg.From, s.GetNonce(msg.From)+1)
+	s.SubBalance(msg.From, msg.Value)
+	s.AddBalance(*msg.To, msg.Value)
+	s.Finalise(true)
+	return s.GetPostState(), substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 20_000), nil
+}
+
+func TestReplayer_EchoExecutor(t *testing.T) {
+	sdb := newTestDB(t)
+	r := &Replayer{DB: sdb, Executor: EchoExecutor{DB: sdb}, First: 0, Last: 10, Workers: 2}
+
+	mismatches, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("unexpected mismatches %v", mismatches)
+	}
+}
+
+func TestReplayer_Tolerance(t *testing.T) {
+	sdb := newTestDB(t)
+	r := &Replayer{DB: sdb, Executor: ExecutorFunc(transfer), First: 0, Last: 10, Workers: 2}
+
+	mismatches, err := r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 3 {
+		t.Fatalf("unexpected number of mismatches %v", mismatches)
+	}
+	if m := mismatches[1]; m.Block != 1 || m.Transaction != 1 {
+		t.Fatalf("mismatches are not ordered: %v", mismatches)
+	}
+
+	r.Tolerance.IgnoreGas = true
+	mismatches, err = r.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("unexpected mismatches %v", mismatches)
+	}
+}
+
+func TestReplayer_FailFast(t *testing.T) {
+	sdb := newTestDB(t)
+	r := &Replayer{DB: sdb, Executor: ExecutorFunc(transfer), First: 0, Last: 10, FailFast: true}
+
+	if _, err := r.Run(); err == nil {
+		t.Fatal("replay must fail on first mismatch")
+	}
+}
+
+func TestValidate_PostState(t *testing.T) {
+	ss := newTransferSubstate(1, 0)
+	post := substate.WorldState{
+		types.Address{1}: substate.NewAccount(2, big.NewInt(90), nil),
+		types.Address{2}: substate.NewAccount(0, big.NewInt(10), nil),
+	}
+	post[types.Address{2}].Storage[types.Hash{1}] = types.Hash{}
+
+	if err := Validate(ss, post, ss.Result, Tolerance{}); err == nil {
+		t.Fatal("zero storage slot must be reported")
+	}
+	if err := Validate(ss, post, ss.Result, Tolerance{IgnoreZeroStorage: true}); err != nil {
+		t.Fatalf("zero storage slot must be ignored; %v", err)
+	}
+	if err := Validate(ss, nil, ss.Result, Tolerance{IgnorePostState: true}); err != nil {
+		t.Fatalf("post state must be ignored; %v", err)
+	}
+}
+
+func TestValidate_StatusAndLogsOnly(t *testing.T) {
+	ss := newTransferSubstate(1, 0)
+	result := substate.NewResult(1, types.Bloom{1}, []*types.Log{}, types.Address{9}, 1)
+
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{StatusAndLogsOnly: true}); err != nil {
+		t.Fatalf("only status and logs must be compared; %v", err)
+	}
+
+	result.Status = 0
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{StatusAndLogsOnly: true}); err == nil {
+		t.Fatal("different status must be reported")
+	}
+}
+
+func TestValidate_ReceiptFields(t *testing.T) {
+	ss := newTransferSubstate(1, 0)
+	txType, cumulativeGasUsed, blobGasUsed := substate.DynamicFeeTxType, uint64(42_000), uint64(0)
+	ss.Result.Type = &txType
+	ss.Result.CumulativeGasUsed = &cumulativeGasUsed
+	ss.Result.EffectiveGasPrice = big.NewInt(2)
+	ss.Result.BlobGasUsed = &blobGasUsed
+
+	// an executor of a single transaction knows no receipt fields
+	result := substate.NewResult(1, types.Bloom{}, []*types.Log{}, types.Address{}, 21_000)
+
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{}); err == nil {
+		t.Fatal("missing receipt fields must be reported")
+	}
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{StatusAndLogsOnly: true}); err != nil {
+		t.Fatalf("receipt fields must be ignored; %v", err)
+	}
+
+	// only gas fields are ignored by IgnoreGas
+	result.Type = &txType
+	result.EffectiveGasPrice = big.NewInt(2)
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{IgnoreGas: true}); err != nil {
+		t.Fatalf("gas fields must be ignored; %v", err)
+	}
+	result.EffectiveGasPrice = big.NewInt(3)
+	if err := Validate(ss, ss.OutputSubstate, result, Tolerance{IgnoreGas: true}); err == nil {
+		t.Fatal("different effective gas price must be reported")
+	}
+}
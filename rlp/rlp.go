@@ -1,6 +1,7 @@
 package rlp
 
 import (
+	"github.com/Fantom-foundation/Substate/chain"
 	"github.com/Fantom-foundation/Substate/substate"
 	"github.com/Fantom-foundation/Substate/types"
 	"github.com/Fantom-foundation/Substate/types/rlp"
@@ -57,6 +58,35 @@ func Decode(val []byte) (*RLP, error) {
 	return nil, err
 }
 
+// DecodeFork decodes val recorded in a block of given fork into RLP and returns it.
+// The layout used by recorders of the fork is tried first. Other layouts are tried
+// afterwards since DBs may be re-recorded in a newer layout.
+func DecodeFork(val []byte, fork chain.Fork) (*RLP, error) {
+	switch {
+	case fork >= chain.Cancun:
+		var substateRLP RLP
+		if err := rlp.DecodeBytes(val, &substateRLP); err == nil {
+			return &substateRLP, nil
+		}
+	case fork < chain.Berlin:
+		var legacy legacySubstateRLP
+		if err := rlp.DecodeBytes(val, &legacy); err == nil {
+			return legacy.toRLP(), nil
+		}
+	case fork < chain.London:
+		var berlin berlinRLP
+		if err := rlp.DecodeBytes(val, &berlin); err == nil {
+			return berlin.toRLP(), nil
+		}
+	default:
+		var london londonRLP
+		if err := rlp.DecodeBytes(val, &london); err == nil {
+			return london.toRLP(), nil
+		}
+	}
+	return Decode(val)
+}
+
 // ToSubstate transforms every attribute of r from RLP to substate.Substate.
 func (r *RLP) ToSubstate(getHashFunc func(codeHash types.Hash) ([]byte, error), block uint64, tx int) (*substate.Substate, error) {
 	msg, err := r.Message.ToSubstate(getHashFunc)
@@ -29,6 +29,18 @@ func NewEnv(env *substate.Env) *Env {
 		e.BlobBaseFee = &blobBaseFee
 	}
 
+	e.PrevRandao = env.PrevRandao
+	e.ParentBeaconBlockRoot = env.ParentBeaconBlockRoot
+	e.ExcessBlobGas = uint64ToList(env.ExcessBlobGas)
+	e.BlobGasUsed = uint64ToList(env.BlobGasUsed)
+	if env.Withdrawals != nil {
+		withdrawals := make([]types.Withdrawal, 0, len(env.Withdrawals))
+		for _, w := range env.Withdrawals {
+			withdrawals = append(withdrawals, *w)
+		}
+		e.Withdrawals = [][]types.Withdrawal{withdrawals}
+	}
+
 	return e
 }
 
@@ -42,6 +54,14 @@ type Env struct {
 
 	BaseFee     *types.Hash `rlp:"nil"` // missing in substate DB from Geth <= v1.10.3
 	BlobBaseFee *types.Hash `rlp:"nil"` // missing in substate DB before Cancun
+
+	// optional fields are missing in substate DB recorded before they were introduced
+	PrevRandao            *types.Hash `rlp:"nil,optional"`
+	ParentBeaconBlockRoot *types.Hash `rlp:"nil,optional"`
+	// values which may be zero or empty are wrapped in a list to tell them apart from unset ones
+	ExcessBlobGas []uint64             `rlp:"optional"`
+	BlobGasUsed   []uint64             `rlp:"optional"`
+	Withdrawals   [][]types.Withdrawal `rlp:"optional"`
 }
 
 // ToSubstate transforms e from Env to substate.Env.
@@ -64,6 +84,19 @@ func (e Env) ToSubstate() *substate.Env {
 		BlockHashes: make(map[uint64]types.Hash),
 		BaseFee:     baseFee,
 		BlobBaseFee: blobBaseFee,
+
+		PrevRandao:            e.PrevRandao,
+		ParentBeaconBlockRoot: e.ParentBeaconBlockRoot,
+		ExcessBlobGas:         listToUint64(e.ExcessBlobGas),
+		BlobGasUsed:           listToUint64(e.BlobGasUsed),
+	}
+
+	if len(e.Withdrawals) > 0 {
+		withdrawals := e.Withdrawals[0]
+		se.Withdrawals = make([]*types.Withdrawal, len(withdrawals))
+		for i := range withdrawals {
+			se.Withdrawals[i] = &withdrawals[i]
+		}
 	}
 
 	// iterate through BlockHashes
@@ -78,3 +111,20 @@ func (e Env) ToSubstate() *substate.Env {
 	return se
 
 }
+
+// uint64ToList returns a list holding value of v or an empty list if v is nil.
+// Unlike *uint64 with the nil tag, zero value is not decoded as nil.
+func uint64ToList(v *uint64) []uint64 {
+	if v == nil {
+		return nil
+	}
+	return []uint64{*v}
+}
+
+func listToUint64(l []uint64) *uint64 {
+	if len(l) == 0 {
+		return nil
+	}
+	v := l[0]
+	return &v
+}
@@ -24,6 +24,16 @@ func NewMessage(sm *substate.Message) *Message {
 		GasTipCap:     sm.GasTipCap,
 		BlobGasFeeCap: sm.BlobGasFeeCap,
 		BlobHashes:    sm.BlobHashes,
+		Type:          uint8ToList(sm.Type),
+		ChainID:       bigToHash(sm.ChainID),
+		TxHash:        sm.TxHash,
+	}
+
+	if mess.To == nil {
+		// put contract creation init code into codeDB
+		dataHash := sm.DataHash()
+		mess.InitCodeHash = &dataHash
+		mess.Data = nil
 	}
 
 	return mess
@@ -49,6 +59,12 @@ type Message struct {
 
 	BlobGasFeeCap *big.Int     // missing in substate DB from Geth before Cancun
 	BlobHashes    []types.Hash // missing in substate DB from Geth before Cancun
+
+	// optional fields are missing in substate DB recorded before they were introduced,
+	// type is wrapped in a list to tell legacy transactions apart from unset type
+	Type    []uint64    `rlp:"optional"`
+	ChainID *types.Hash `rlp:"nil,optional"`
+	TxHash  *types.Hash `rlp:"nil,optional"`
 }
 
 // ToSubstate transforms m from Message to substate.Message.
@@ -67,6 +83,9 @@ func (m Message) ToSubstate(getHashFunc func(codeHash types.Hash) ([]byte, error
 		GasTipCap:     m.GasTipCap,
 		BlobGasFeeCap: m.BlobGasFeeCap,
 		BlobHashes:    m.BlobHashes,
+		Type:          listToUint8(m.Type),
+		ChainID:       hashToBig(m.ChainID),
+		TxHash:        m.TxHash,
 	}
 
 	// if receiver is nil, we have to extract the data from the DB using getHashFunc
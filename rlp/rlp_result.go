@@ -1,6 +1,8 @@
 package rlp
 
 import (
+	"math/big"
+
 	"github.com/Fantom-foundation/Substate/substate"
 	"github.com/Fantom-foundation/Substate/types"
 )
@@ -12,6 +14,12 @@ func NewResult(result *substate.Result) *Result {
 		Logs:            result.Logs,
 		ContractAddress: result.ContractAddress,
 		GasUsed:         result.GasUsed,
+
+		Type:              uint8ToList(result.Type),
+		CumulativeGasUsed: uint64ToList(result.CumulativeGasUsed),
+		EffectiveGasPrice: bigToHash(result.EffectiveGasPrice),
+		BlobGasUsed:       uint64ToList(result.BlobGasUsed),
+		BlobGasPrice:      bigToHash(result.BlobGasPrice),
 	}
 }
 
@@ -22,6 +30,14 @@ type Result struct {
 
 	ContractAddress types.Address
 	GasUsed         uint64
+
+	// optional fields are missing in substate DB recorded before they were introduced,
+	// integers are wrapped in a list to tell zero apart from unset
+	Type              []uint64    `rlp:"optional"`
+	CumulativeGasUsed []uint64    `rlp:"optional"`
+	EffectiveGasPrice *types.Hash `rlp:"nil,optional"`
+	BlobGasUsed       []uint64    `rlp:"optional"`
+	BlobGasPrice      *types.Hash `rlp:"nil,optional"`
 }
 
 // ToSubstate transforms r from Result to substate.Result.
@@ -32,5 +48,43 @@ func (r Result) ToSubstate() *substate.Result {
 		Logs:            r.Logs,
 		ContractAddress: r.ContractAddress,
 		GasUsed:         r.GasUsed,
+
+		Type:              listToUint8(r.Type),
+		CumulativeGasUsed: listToUint64(r.CumulativeGasUsed),
+		EffectiveGasPrice: hashToBig(r.EffectiveGasPrice),
+		BlobGasUsed:       listToUint64(r.BlobGasUsed),
+		BlobGasPrice:      hashToBig(r.BlobGasPrice),
+	}
+}
+
+// bigToHash returns b as hash, nil is kept. Unlike *big.Int, nil *types.Hash survives the encoding.
+func bigToHash(b *big.Int) *types.Hash {
+	if b == nil {
+		return nil
+	}
+	h := types.BigToHash(b)
+	return &h
+}
+
+func hashToBig(h *types.Hash) *big.Int {
+	if h == nil {
+		return nil
+	}
+	return h.Big()
+}
+
+// uint8ToList returns a list holding value of v or an empty list if v is nil, see uint64ToList.
+func uint8ToList(v *uint8) []uint64 {
+	if v == nil {
+		return nil
+	}
+	return []uint64{uint64(*v)}
+}
+
+func listToUint8(l []uint64) *uint8 {
+	if len(l) == 0 {
+		return nil
 	}
+	v := uint8(l[0])
+	return &v
 }
@@ -5,6 +5,8 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/substate"
 	"github.com/Fantom-foundation/Substate/types"
 	"github.com/Fantom-foundation/Substate/types/rlp"
 )
@@ -146,3 +148,303 @@ func Test_Message_ToSubstate_CorrectlyAssignsDataIfContractCreation(t *testing.T
 		t.Fatalf("unexpected data\ngot: %v\n want: %v", wantedData, m.Data)
 	}
 }
+
+func Test_NewMessage_StoresInitCodeHashIfContractCreation(t *testing.T) {
+	sm := substate.NewMessage(1, true, big.NewInt(1), 1, types.Address{1}, nil, big.NewInt(1), []byte{2}, nil, nil, big.NewInt(1), big.NewInt(1), nil, nil)
+
+	m := NewMessage(sm)
+	if m.InitCodeHash == nil {
+		t.Fatal("init code hash must be set for contract creation")
+	}
+	if *m.InitCodeHash != sm.DataHash() {
+		t.Fatalf("unexpected init code hash\ngot: %v\nwant: %v", *m.InitCodeHash, sm.DataHash())
+	}
+	if m.Data != nil {
+		t.Fatal("init code must not be stored within the message")
+	}
+}
+
+// cancunEnv is the layout of Env before post-merge fields were added.
+type cancunEnv struct {
+	Coinbase    types.Address
+	Difficulty  *big.Int
+	GasLimit    uint64
+	Number      uint64
+	Timestamp   uint64
+	BlockHashes [][2]types.Hash
+
+	BaseFee     *types.Hash `rlp:"nil"`
+	BlobBaseFee *types.Hash `rlp:"nil"`
+}
+
+func Test_DecodeEnvWithoutPostMergeFields(t *testing.T) {
+	b, err := rlp.EncodeToBytes(cancunEnv{Difficulty: big.NewInt(1), Number: 5, BlobBaseFee: &hash1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env Env
+	if err = rlp.DecodeBytes(b, &env); err != nil {
+		t.Fatal(err)
+	}
+	se := env.ToSubstate()
+	if se.Number != 5 || se.BlobBaseFee == nil || se.PrevRandao != nil || se.ExcessBlobGas != nil || se.Withdrawals != nil {
+		t.Fatalf("unexpected env %v", se)
+	}
+}
+
+func Test_EncodeEnvPostMergeFields(t *testing.T) {
+	excess, used := uint64(1), uint64(2)
+	env := &substate.Env{
+		Difficulty:            big.NewInt(0),
+		BlockHashes:           map[uint64]types.Hash{},
+		PrevRandao:            &hash1,
+		ParentBeaconBlockRoot: &types.Hash{2},
+		ExcessBlobGas:         &excess,
+		BlobGasUsed:           &used,
+		Withdrawals:           []*types.Withdrawal{{Index: 1, Validator: 2, Address: addr1, Amount: 3}},
+	}
+
+	b, err := rlp.EncodeToBytes(NewEnv(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Env
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.ToSubstate(); !got.Equal(env) {
+		t.Fatalf("unexpected env\ngot: %v\nwant: %v", got, env)
+	}
+
+	// older layouts must not accept the extended env
+	b, err = rlp.EncodeToBytes(RLP{
+		Message: &Message{Value: big.NewInt(1), GasPrice: big.NewInt(1)},
+		Env:     NewEnv(env),
+		Result:  &Result{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.Env.ToSubstate(); !got.Equal(env) {
+		t.Fatalf("unexpected env\ngot: %v\nwant: %v", got, env)
+	}
+
+	// without the withdrawals, the preceding optional fields must be kept
+	env.Withdrawals = nil
+	env.PrevRandao = nil
+	if b, err = rlp.EncodeToBytes(NewEnv(env)); err != nil {
+		t.Fatal(err)
+	}
+	decoded = Env{}
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.ToSubstate(); !got.Equal(env) {
+		t.Fatalf("unexpected env\ngot: %v\nwant: %v", got, env)
+	}
+}
+
+func Test_EncodeEnvZeroPostCancunFields(t *testing.T) {
+	zero := uint64(0)
+	for _, withdrawals := range [][]*types.Withdrawal{nil, {}} {
+		env := &substate.Env{
+			Difficulty:    big.NewInt(0),
+			BlockHashes:   map[uint64]types.Hash{},
+			ExcessBlobGas: &zero,
+			BlobGasUsed:   &zero,
+			Withdrawals:   withdrawals,
+		}
+
+		b, err := rlp.EncodeToBytes(NewEnv(env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Env
+		if err = rlp.DecodeBytes(b, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		got := decoded.ToSubstate()
+		if !got.Equal(env) {
+			t.Fatalf("unexpected env\ngot: %v\nwant: %v", got, env)
+		}
+		// zero and empty values must not be decoded as unset
+		if got.ExcessBlobGas == nil || got.BlobGasUsed == nil || (got.Withdrawals == nil) != (withdrawals == nil) {
+			t.Fatalf("zero values are lost\ngot: %v\nwant: %v", got, env)
+		}
+	}
+}
+
+// londonResult is the layout of Result before optional receipt fields were added.
+type londonResult struct {
+	Status uint64
+	Bloom  types.Bloom
+	Logs   []*types.Log
+
+	ContractAddress types.Address
+	GasUsed         uint64
+}
+
+func Test_DecodeResultWithoutReceiptFields(t *testing.T) {
+	b, err := rlp.EncodeToBytes(londonResult{Status: 1, GasUsed: 21_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res Result
+	if err = rlp.DecodeBytes(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if sr := res.ToSubstate(); sr.GasUsed != 21_000 || sr.Type != nil || sr.EffectiveGasPrice != nil {
+		t.Fatalf("unexpected result %v", sr)
+	}
+}
+
+func Test_EncodeResultReceiptFields(t *testing.T) {
+	txType, cumulativeGasUsed, blobGasUsed := uint8(3), uint64(42_000), uint64(131_072)
+	res := &substate.Result{
+		Status:            1,
+		Logs:              []*types.Log{},
+		GasUsed:           21_000,
+		Type:              &txType,
+		CumulativeGasUsed: &cumulativeGasUsed,
+		EffectiveGasPrice: big.NewInt(7),
+		BlobGasUsed:       &blobGasUsed,
+		BlobGasPrice:      big.NewInt(1),
+	}
+
+	b, err := rlp.EncodeToBytes(NewResult(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.ToSubstate(); !got.Equal(res) {
+		t.Fatalf("unexpected result\ngot: %v\nwant: %v", got, res)
+	}
+
+	// unset prices followed by set fields must stay unset
+	res.EffectiveGasPrice = nil
+	if b, err = rlp.EncodeToBytes(NewResult(res)); err != nil {
+		t.Fatal(err)
+	}
+	decoded = Result{}
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if got := decoded.ToSubstate(); !got.Equal(res) {
+		t.Fatalf("unexpected result\ngot: %v\nwant: %v", got, res)
+	}
+}
+
+func Test_EncodeMessageTypeChainIdAndTxHash(t *testing.T) {
+	txType := substate.DynamicFeeTxType
+	msg := substate.NewMessage(1, true, big.NewInt(1), 21_000, addr1, &addr1, big.NewInt(1), nil, nil, types.AccessList{}, big.NewInt(2), big.NewInt(1), big.NewInt(0), nil)
+	msg.Type = &txType
+	msg.TxHash = &hash1
+
+	b, err := rlp.EncodeToBytes(NewMessage(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Message
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decoded.ToSubstate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ChainID was not recorded and must stay nil
+	if !got.Equal(msg) || got.ChainID != nil {
+		t.Fatalf("unexpected message\ngot: %v\nwant: %v", got, msg)
+	}
+}
+
+func Test_DecodeFork(t *testing.T) {
+	legacy, err := rlp.EncodeToBytes(legacySubstateRLP{
+		Message: &legacyMessage{Data: []byte{1}, Value: big.NewInt(1), GasPrice: big.NewInt(1)},
+		Env:     &legacyEnv{},
+		Result:  &Result{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := rlp.EncodeToBytes(RLP{
+		Message: &Message{Data: []byte{2}, Value: big.NewInt(1), GasPrice: big.NewInt(1)},
+		Env:     &Env{},
+		Result:  &Result{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// layouts not matching the fork are decoded as well
+	for _, fork := range []chain.Fork{chain.Istanbul, chain.Berlin, chain.London, chain.Cancun} {
+		for want, val := range map[byte][]byte{1: legacy, 2: current} {
+			res, err := DecodeFork(val, fork)
+			if err != nil {
+				t.Fatalf("%v: %v", fork, err)
+			}
+			if !bytes.Equal(res.Message.Data, []byte{want}) {
+				t.Fatalf("%v: incorrect data\ngot: %v\nwant: %v", fork, res.Message.Data, []byte{want})
+			}
+		}
+	}
+}
+
+func Test_EncodeResultZeroReceiptFields(t *testing.T) {
+	txType, cumulativeGasUsed, blobGasUsed := substate.LegacyTxType, uint64(0), uint64(0)
+	res := &substate.Result{
+		Status:            1,
+		Logs:              []*types.Log{},
+		Type:              &txType,
+		CumulativeGasUsed: &cumulativeGasUsed,
+		EffectiveGasPrice: big.NewInt(0),
+		BlobGasUsed:       &blobGasUsed,
+	}
+
+	b, err := rlp.EncodeToBytes(NewResult(res))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Result
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got := decoded.ToSubstate()
+	if !got.Equal(res) {
+		t.Fatalf("unexpected result\ngot: %v\nwant: %v", got, res)
+	}
+	// zero values must not be decoded as unset
+	if got.Type == nil || got.CumulativeGasUsed == nil || got.BlobGasUsed == nil || got.BlobGasPrice != nil {
+		t.Fatalf("zero values are lost\ngot: %v\nwant: %v", got, res)
+	}
+}
+
+func Test_EncodeMessageLegacyType(t *testing.T) {
+	txType := substate.LegacyTxType
+	msg := substate.NewMessage(1, true, big.NewInt(1), 21_000, addr1, &addr1, big.NewInt(1), nil, nil, types.AccessList{}, big.NewInt(1), big.NewInt(1), big.NewInt(0), nil)
+	msg.Type = &txType
+
+	b, err := rlp.EncodeToBytes(NewMessage(msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Message
+	if err = rlp.DecodeBytes(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decoded.ToSubstate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// type zero must not be decoded as unset
+	if !got.Equal(msg) || got.Type == nil {
+		t.Fatalf("unexpected message\ngot: %v\nwant: %v", got, msg)
+	}
+}
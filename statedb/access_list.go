@@ -0,0 +1,79 @@
+package statedb
+
+import (
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// accessList tracks addresses and storage slots accessed within a transaction (EIP-2929).
+type accessList struct {
+	addresses map[types.Address]map[types.Hash]struct{}
+}
+
+func newAccessList() *accessList {
+	return &accessList{addresses: make(map[types.Address]map[types.Hash]struct{})}
+}
+
+// Prepare resets the access list and transient storage (EIP-1153) before executing a transaction in fork.
+// Since Berlin, sender, destination, precompiles and entries of the transaction access list are added
+// to the access list (EIP-2929, EIP-2930). Since Shanghai, the coinbase is added as well (EIP-3651).
+func (s *StateDB) Prepare(fork chain.Fork, sender, coinbase types.Address, dst *types.Address, precompiles []types.Address, list types.AccessList) {
+	s.accessList = newAccessList()
+	s.transient = make(map[types.Address]map[types.Hash]types.Hash)
+	if fork < chain.Berlin {
+		return
+	}
+
+	s.AddAddressToAccessList(sender)
+	if dst != nil {
+		s.AddAddressToAccessList(*dst)
+	}
+	for _, addr := range precompiles {
+		s.AddAddressToAccessList(addr)
+	}
+	for _, tuple := range list {
+		s.AddAddressToAccessList(tuple.Address)
+		for _, key := range tuple.StorageKeys {
+			s.AddSlotToAccessList(tuple.Address, key)
+		}
+	}
+	if fork >= chain.Shanghai {
+		s.AddAddressToAccessList(coinbase)
+	}
+}
+
+// AddressInAccessList returns true if addr is in the access list.
+func (s *StateDB) AddressInAccessList(addr types.Address) bool {
+	_, found := s.accessList.addresses[addr]
+	return found
+}
+
+// SlotInAccessList returns whether addr and slot of addr are in the access list.
+func (s *StateDB) SlotInAccessList(addr types.Address, key types.Hash) (addressOk bool, slotOk bool) {
+	slots, addressOk := s.accessList.addresses[addr]
+	if !addressOk {
+		return false, false
+	}
+	_, slotOk = slots[key]
+	return addressOk, slotOk
+}
+
+// AddAddressToAccessList adds addr to the access list.
+func (s *StateDB) AddAddressToAccessList(addr types.Address) {
+	if s.AddressInAccessList(addr) {
+		return
+	}
+	s.accessList.addresses[addr] = make(map[types.Hash]struct{})
+	s.journal.append(nil, func() { delete(s.accessList.addresses, addr) })
+}
+
+// AddSlotToAccessList adds addr and its storage key to the access list.
+func (s *StateDB) AddSlotToAccessList(addr types.Address, key types.Hash) {
+	s.AddAddressToAccessList(addr)
+	slots := s.accessList.addresses[addr]
+	if _, found := slots[key]; found {
+		return
+	}
+	slots[key] = struct{}{}
+	s.journal.append(nil, func() { delete(slots, key) })
+}
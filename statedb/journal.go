@@ -0,0 +1,45 @@
+package statedb
+
+import "github.com/Fantom-foundation/Substate/types"
+
+// change is a revertible modification of the StateDB.
+type change struct {
+	addr *types.Address // account touched by the change, nil if no account is touched
+	undo func()
+}
+
+// journal records changes since the last Finalise, so they can be reverted to a snapshot.
+type journal struct {
+	changes []change
+}
+
+func (j *journal) append(addr *types.Address, undo func()) {
+	j.changes = append(j.changes, change{addr: addr, undo: undo})
+}
+
+func (j *journal) length() int {
+	return len(j.changes)
+}
+
+// revert undoes all changes after the given length in reverse order.
+func (j *journal) revert(length int) {
+	for i := len(j.changes) - 1; i >= length; i-- {
+		j.changes[i].undo()
+	}
+	j.changes = j.changes[:length]
+}
+
+// touched returns all accounts touched by recorded changes.
+func (j *journal) touched() map[types.Address]struct{} {
+	touched := make(map[types.Address]struct{})
+	for _, c := range j.changes {
+		if c.addr != nil {
+			touched[*c.addr] = struct{}{}
+		}
+	}
+	return touched
+}
+
+func (j *journal) reset() {
+	j.changes = j.changes[:0]
+}
@@ -0,0 +1,345 @@
+// Package statedb implements an in-memory state for replaying substates offline.
+package statedb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+// emptyCodeHash is the Keccak256 hash of empty code.
+var emptyCodeHash = hash.Keccak256Hash(nil)
+
+// StateDB is a state over an InputSubstate in the shape expected by EVM implementations.
+// All changes are journaled and may be reverted to a snapshot until Finalise is called.
+// StateDB is not safe for concurrent use.
+type StateDB struct {
+	state     substate.WorldState // current state
+	committed substate.WorldState // state at the beginning of the transaction
+
+	// per transaction data, cleared by Finalise
+	created    map[types.Address]struct{}
+	destructed map[types.Address]struct{}
+	transient  map[types.Address]map[types.Hash]types.Hash
+	accessList *accessList
+	refund     uint64
+	logs       []*types.Log
+	journal    *journal
+}
+
+// New returns StateDB over a copy of ws. The ws itself is never modified.
+func New(ws substate.WorldState) *StateDB {
+	s := &StateDB{
+		state:     copyWorldState(ws),
+		committed: copyWorldState(ws),
+		journal:   &journal{},
+	}
+	s.clearTransaction()
+	return s
+}
+
+// CreateAccount creates a new empty account at addr. Balance of an existing account is preserved.
+func (s *StateDB) CreateAccount(addr types.Address) {
+	prev := s.state[addr]
+	balance := new(big.Int)
+	if prev != nil {
+		balance = prev.Balance
+	}
+	s.state[addr] = substate.NewAccount(0, balance, nil)
+
+	_, wasCreated := s.created[addr]
+	s.created[addr] = struct{}{}
+	s.journal.append(&addr, func() {
+		if prev == nil {
+			delete(s.state, addr)
+		} else {
+			s.state[addr] = prev
+		}
+		if !wasCreated {
+			delete(s.created, addr)
+		}
+	})
+}
+
+// Exist returns true if account of addr exists, including self-destructed accounts.
+func (s *StateDB) Exist(addr types.Address) bool {
+	_, found := s.state[addr]
+	return found
+}
+
+// Empty returns true if account of addr does not exist or has zero nonce, balance and no code (EIP-161).
+func (s *StateDB) Empty(addr types.Address) bool {
+	acc := s.state[addr]
+	return acc == nil || (acc.Nonce == 0 && acc.Balance.Sign() == 0 && len(acc.Code) == 0)
+}
+
+// GetBalance returns balance of addr or zero if the account does not exist.
+func (s *StateDB) GetBalance(addr types.Address) *big.Int {
+	if acc := s.state[addr]; acc != nil {
+		return new(big.Int).Set(acc.Balance)
+	}
+	return new(big.Int)
+}
+
+// AddBalance adds amount to balance of addr. Adding zero still touches the account.
+func (s *StateDB) AddBalance(addr types.Address, amount *big.Int) {
+	s.SetBalance(addr, new(big.Int).Add(s.GetBalance(addr), amount))
+}
+
+// SubBalance subtracts amount from balance of addr.
+func (s *StateDB) SubBalance(addr types.Address, amount *big.Int) {
+	s.SetBalance(addr, new(big.Int).Sub(s.GetBalance(addr), amount))
+}
+
+// SetBalance sets balance of addr.
+func (s *StateDB) SetBalance(addr types.Address, balance *big.Int) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Balance
+	acc.Balance = new(big.Int).Set(balance)
+	s.journal.append(&addr, func() { acc.Balance = prev })
+}
+
+// GetNonce returns nonce of addr or zero if the account does not exist.
+func (s *StateDB) GetNonce(addr types.Address) uint64 {
+	if acc := s.state[addr]; acc != nil {
+		return acc.Nonce
+	}
+	return 0
+}
+
+// SetNonce sets nonce of addr.
+func (s *StateDB) SetNonce(addr types.Address, nonce uint64) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Nonce
+	acc.Nonce = nonce
+	s.journal.append(&addr, func() { acc.Nonce = prev })
+}
+
+// GetCode returns code of addr.
+func (s *StateDB) GetCode(addr types.Address) []byte {
+	if acc := s.state[addr]; acc != nil {
+		return acc.Code
+	}
+	return nil
+}
+
+// GetCodeSize returns size of code of addr.
+func (s *StateDB) GetCodeSize(addr types.Address) int {
+	return len(s.GetCode(addr))
+}
+
+// GetCodeHash returns hash of code of addr or zero hash if the account does not exist.
+func (s *StateDB) GetCodeHash(addr types.Address) types.Hash {
+	acc := s.state[addr]
+	if acc == nil {
+		return types.Hash{}
+	}
+	if len(acc.Code) == 0 {
+		return emptyCodeHash
+	}
+	return acc.CodeHash()
+}
+
+// SetCode sets code of addr.
+func (s *StateDB) SetCode(addr types.Address, code []byte) {
+	acc := s.getOrCreate(addr)
+	prev := acc.Code
+	acc.Code = append([]byte{}, code...)
+	s.journal.append(&addr, func() { acc.Code = prev })
+}
+
+// GetState returns current value of storage key of addr.
+func (s *StateDB) GetState(addr types.Address, key types.Hash) types.Hash {
+	if acc := s.state[addr]; acc != nil {
+		return acc.Storage[key]
+	}
+	return types.Hash{}
+}
+
+// GetCommittedState returns value of storage key of addr at the beginning of the transaction.
+func (s *StateDB) GetCommittedState(addr types.Address, key types.Hash) types.Hash {
+	if _, created := s.created[addr]; created {
+		return types.Hash{}
+	}
+	if acc := s.committed[addr]; acc != nil {
+		return acc.Storage[key]
+	}
+	return types.Hash{}
+}
+
+// SetState sets value of storage key of addr.
+func (s *StateDB) SetState(addr types.Address, key types.Hash, value types.Hash) {
+	acc := s.getOrCreate(addr)
+	prev, found := acc.Storage[key]
+	acc.Storage[key] = value
+	s.journal.append(&addr, func() {
+		if found {
+			acc.Storage[key] = prev
+		} else {
+			delete(acc.Storage, key)
+		}
+	})
+}
+
+// GetTransientState returns value of transient storage key of addr (EIP-1153).
+func (s *StateDB) GetTransientState(addr types.Address, key types.Hash) types.Hash {
+	return s.transient[addr][key]
+}
+
+// SetTransientState sets value of transient storage key of addr (EIP-1153).
+func (s *StateDB) SetTransientState(addr types.Address, key types.Hash, value types.Hash) {
+	prev := s.GetTransientState(addr, key)
+	s.setTransient(addr, key, value)
+	s.journal.append(nil, func() { s.setTransient(addr, key, prev) })
+}
+
+func (s *StateDB) setTransient(addr types.Address, key types.Hash, value types.Hash) {
+	slots, found := s.transient[addr]
+	if !found {
+		slots = make(map[types.Hash]types.Hash)
+		s.transient[addr] = slots
+	}
+	slots[key] = value
+}
+
+// SelfDestruct marks account of addr as destroyed and clears its balance.
+// The account is removed by Finalise.
+func (s *StateDB) SelfDestruct(addr types.Address) {
+	acc := s.state[addr]
+	if acc == nil {
+		return
+	}
+
+	_, wasDestructed := s.destructed[addr]
+	prevBalance := acc.Balance
+	s.destructed[addr] = struct{}{}
+	acc.Balance = new(big.Int)
+	s.journal.append(&addr, func() {
+		acc.Balance = prevBalance
+		if !wasDestructed {
+			delete(s.destructed, addr)
+		}
+	})
+}
+
+// SelfDestruct6780 destroys account of addr only if it was created within the current transaction (EIP-6780).
+func (s *StateDB) SelfDestruct6780(addr types.Address) {
+	if _, created := s.created[addr]; created {
+		s.SelfDestruct(addr)
+	}
+}
+
+// HasSelfDestructed returns true if account of addr was destroyed within the current transaction.
+func (s *StateDB) HasSelfDestructed(addr types.Address) bool {
+	_, found := s.destructed[addr]
+	return found
+}
+
+// AddRefund adds gas to the refund counter.
+func (s *StateDB) AddRefund(gas uint64) {
+	prev := s.refund
+	s.refund += gas
+	s.journal.append(nil, func() { s.refund = prev })
+}
+
+// SubRefund removes gas from the refund counter. It panics if the counter would go below zero.
+func (s *StateDB) SubRefund(gas uint64) {
+	prev := s.refund
+	if gas > s.refund {
+		panic(fmt.Sprintf("refund counter below zero (gas: %d > refund: %d)", gas, s.refund))
+	}
+	s.refund -= gas
+	s.journal.append(nil, func() { s.refund = prev })
+}
+
+// GetRefund returns the current value of the refund counter.
+func (s *StateDB) GetRefund() uint64 {
+	return s.refund
+}
+
+// AddLog appends log emitted by the current transaction. Index is set to position of the log within the transaction.
+func (s *StateDB) AddLog(log *types.Log) {
+	log.Index = uint(len(s.logs))
+	s.logs = append(s.logs, log)
+	s.journal.append(nil, func() { s.logs = s.logs[:len(s.logs)-1] })
+}
+
+// GetLogs returns logs emitted by the current transaction.
+func (s *StateDB) GetLogs() []*types.Log {
+	return s.logs
+}
+
+// Snapshot returns identifier of the current state which can be used by RevertToSnapshot.
+func (s *StateDB) Snapshot() int {
+	return s.journal.length()
+}
+
+// RevertToSnapshot reverts all changes made since the given snapshot.
+// Snapshots taken after the given snapshot become invalid.
+func (s *StateDB) RevertToSnapshot(id int) {
+	if id < 0 || id > s.journal.length() {
+		panic(fmt.Sprintf("revision id %v cannot be reverted", id))
+	}
+	s.journal.revert(id)
+}
+
+// Finalise ends the current transaction. Self-destructed accounts are removed and, if deleteEmptyObjects
+// is set, empty accounts touched by the transaction are removed as well (EIP-161).
+// Journal, refunds, logs, transient storage and access list are cleared.
+func (s *StateDB) Finalise(deleteEmptyObjects bool) {
+	for addr := range s.destructed {
+		delete(s.state, addr)
+	}
+	if deleteEmptyObjects {
+		for addr := range s.journal.touched() {
+			if s.Exist(addr) && s.Empty(addr) {
+				delete(s.state, addr)
+			}
+		}
+	}
+
+	s.committed = copyWorldState(s.state)
+	s.clearTransaction()
+}
+
+// GetPostState returns a copy of the current state including not yet finalised changes.
+// Self-destructed accounts are excluded.
+func (s *StateDB) GetPostState() substate.WorldState {
+	ws := copyWorldState(s.state)
+	for addr := range s.destructed {
+		delete(ws, addr)
+	}
+	return ws
+}
+
+func (s *StateDB) clearTransaction() {
+	s.created = make(map[types.Address]struct{})
+	s.destructed = make(map[types.Address]struct{})
+	s.transient = make(map[types.Address]map[types.Hash]types.Hash)
+	s.accessList = newAccessList()
+	s.refund = 0
+	s.logs = nil
+	s.journal.reset()
+}
+
+// getOrCreate returns account of addr. Missing account is created and the creation is journaled.
+func (s *StateDB) getOrCreate(addr types.Address) *substate.Account {
+	if acc := s.state[addr]; acc != nil {
+		return acc
+	}
+	acc := substate.NewAccount(0, new(big.Int), nil)
+	s.state[addr] = acc
+	s.journal.append(&addr, func() { delete(s.state, addr) })
+	return acc
+}
+
+func copyWorldState(ws substate.WorldState) substate.WorldState {
+	cp := make(substate.WorldState, len(ws))
+	for addr, acc := range ws {
+		cp[addr] = acc.Copy()
+	}
+	return cp
+}
@@ -0,0 +1,191 @@
+package statedb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/chain"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+var (
+	alice    = types.Address{1}
+	bob      = types.Address{2}
+	contract = types.Address{3}
+)
+
+func newTestInput() substate.WorldState {
+	ws := substate.NewWorldState().
+		Add(alice, 1, big.NewInt(100), nil).
+		Add(contract, 1, big.NewInt(0), []byte{0x60})
+	ws[contract].Storage[types.Hash{1}] = types.Hash{0x11}
+	return ws
+}
+
+func TestStateDB_PostState(t *testing.T) {
+	input := newTestInput()
+	s := New(input)
+
+	s.SubBalance(alice, big.NewInt(10))
+	s.SetNonce(alice, 2)
+	s.AddBalance(bob, big.NewInt(10))
+	s.SetState(contract, types.Hash{1}, types.Hash{0x22})
+
+	want := substate.NewWorldState().
+		Add(alice, 2, big.NewInt(90), nil).
+		Add(bob, 0, big.NewInt(10), nil).
+		Add(contract, 1, big.NewInt(0), []byte{0x60})
+	want[contract].Storage[types.Hash{1}] = types.Hash{0x22}
+
+	if got := s.GetPostState(); !got.Equal(want) {
+		t.Fatalf("unexpected post state\ngot: %v\nwant: %v", got, want)
+	}
+	if !input.Equal(newTestInput()) {
+		t.Fatal("input substate must not be modified")
+	}
+	if got := s.GetCommittedState(contract, types.Hash{1}); got != (types.Hash{0x11}) {
+		t.Fatalf("unexpected committed state %v", got)
+	}
+}
+
+func TestStateDB_RevertToSnapshot(t *testing.T) {
+	s := New(newTestInput())
+
+	s.SetBalance(alice, big.NewInt(50))
+	snapshot := s.Snapshot()
+
+	s.SetBalance(alice, big.NewInt(0))
+	s.CreateAccount(bob)
+	s.SetState(contract, types.Hash{1}, types.Hash{})
+	s.SetState(contract, types.Hash{2}, types.Hash{0x02})
+	s.SetTransientState(contract, types.Hash{1}, types.Hash{0x01})
+	s.AddSlotToAccessList(contract, types.Hash{1})
+	s.AddRefund(10)
+	s.AddLog(&types.Log{Address: contract})
+	s.SelfDestruct(contract)
+
+	s.RevertToSnapshot(snapshot)
+
+	if got := s.GetBalance(alice); got.Cmp(big.NewInt(50)) != 0 {
+		t.Fatalf("unexpected balance %v", got)
+	}
+	if s.Exist(bob) {
+		t.Fatal("created account must be reverted")
+	}
+	if got := s.GetState(contract, types.Hash{1}); got != (types.Hash{0x11}) {
+		t.Fatalf("unexpected storage value %v", got)
+	}
+	if _, found := s.state[contract].Storage[types.Hash{2}]; found {
+		t.Fatal("new storage slot must be reverted")
+	}
+	if got := s.GetTransientState(contract, types.Hash{1}); got != (types.Hash{}) {
+		t.Fatalf("unexpected transient value %v", got)
+	}
+	if s.AddressInAccessList(contract) {
+		t.Fatal("access list must be reverted")
+	}
+	if s.GetRefund() != 0 || len(s.GetLogs()) != 0 {
+		t.Fatalf("unexpected refund %v or logs %v", s.GetRefund(), s.GetLogs())
+	}
+	if s.HasSelfDestructed(contract) {
+		t.Fatal("self-destruct must be reverted")
+	}
+}
+
+func TestStateDB_Finalise(t *testing.T) {
+	s := New(newTestInput())
+
+	s.SetTransientState(contract, types.Hash{1}, types.Hash{0x01})
+	s.AddBalance(bob, big.NewInt(0)) // touches an empty account
+	s.SelfDestruct(contract)
+	s.Finalise(true)
+
+	if s.Exist(contract) || s.Exist(bob) {
+		t.Fatalf("destroyed and empty accounts must be removed\ngot: %v", s.GetPostState())
+	}
+	if !s.Exist(alice) {
+		t.Fatal("untouched account must be kept")
+	}
+	if got := s.GetTransientState(contract, types.Hash{1}); got != (types.Hash{}) {
+		t.Fatalf("transient storage must be cleared, got %v", got)
+	}
+}
+
+func TestStateDB_SelfDestruct6780(t *testing.T) {
+	s := New(newTestInput())
+
+	s.SelfDestruct6780(contract)
+	if s.HasSelfDestructed(contract) {
+		t.Fatal("pre-existing contract must not be destroyed")
+	}
+
+	created := types.Address{4}
+	s.CreateAccount(created)
+	s.SetCode(created, []byte{0x60})
+	s.SelfDestruct6780(created)
+	if !s.HasSelfDestructed(created) {
+		t.Fatal("contract created within the transaction must be destroyed")
+	}
+	if _, found := s.GetPostState()[created]; found {
+		t.Fatal("destroyed contract must not be in post state")
+	}
+}
+
+func TestStateDB_Prepare(t *testing.T) {
+	coinbase := types.Address{8}
+	tests := []struct {
+		fork       chain.Fork
+		accessList bool
+		coinbase   bool
+	}{
+		{chain.Istanbul, false, false},
+		{chain.Berlin, true, false},
+		{chain.Shanghai, true, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fork.String(), func(t *testing.T) {
+			s := New(newTestInput())
+			s.SetTransientState(contract, types.Hash{1}, types.Hash{0x01})
+			s.Prepare(test.fork, alice, coinbase, &bob, []types.Address{{9}}, types.AccessList{{Address: contract, StorageKeys: []types.Hash{{1}}}})
+
+			if got := s.GetTransientState(contract, types.Hash{1}); got != (types.Hash{}) {
+				t.Fatalf("transient storage must be reset, got %v", got)
+			}
+			for _, addr := range []types.Address{alice, bob, {9}, contract} {
+				if got := s.AddressInAccessList(addr); got != test.accessList {
+					t.Fatalf("unexpected access list membership of %v\ngot: %v\nwant: %v", addr, got, test.accessList)
+				}
+			}
+			if addressOk, slotOk := s.SlotInAccessList(contract, types.Hash{1}); addressOk != test.accessList || slotOk != test.accessList {
+				t.Fatalf("unexpected access list membership of slot\ngot: %v\nwant: %v", slotOk, test.accessList)
+			}
+			if _, slotOk := s.SlotInAccessList(contract, types.Hash{2}); slotOk {
+				t.Fatal("slot must not be in access list")
+			}
+			if got := s.AddressInAccessList(coinbase); got != test.coinbase {
+				t.Fatalf("unexpected access list membership of coinbase\ngot: %v\nwant: %v", got, test.coinbase)
+			}
+		})
+	}
+}
+
+func TestStateDB_SubRefundBelowZeroPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	New(nil).SubRefund(1)
+}
+
+func TestStateDB_GetCodeHash(t *testing.T) {
+	s := New(newTestInput())
+	if got := s.GetCodeHash(bob); got != (types.Hash{}) {
+		t.Fatalf("missing account must have zero code hash, got %v", got)
+	}
+	if got := s.GetCodeHash(alice); got != emptyCodeHash {
+		t.Fatalf("account without code must have empty code hash, got %v", got)
+	}
+}
@@ -20,6 +20,16 @@ type Env struct {
 	BaseFee *big.Int // nil if EIP-1559 is not activated
 	// Cancun hard fork EIP-4844
 	BlobBaseFee *big.Int // nil if EIP-4844 is not activated
+
+	// Paris hard fork, EIP-4399
+	PrevRandao *types.Hash // nil before the merge, Difficulty holds the randomness before
+	// Shanghai hard fork, EIP-4895
+	Withdrawals []*types.Withdrawal // nil if EIP-4895 is not activated, empty if a block has no withdrawals
+	// Cancun hard fork EIP-4788
+	ParentBeaconBlockRoot *types.Hash // nil if EIP-4788 is not activated
+	// Cancun hard fork EIP-4844
+	ExcessBlobGas *uint64 // nil if EIP-4844 is not activated
+	BlobGasUsed   *uint64 // nil if EIP-4844 is not activated
 }
 
 func NewEnv(
@@ -61,11 +71,23 @@ func (e *Env) Equal(y *Env) bool {
 		e.Timestamp == y.Timestamp &&
 		len(e.BlockHashes) == len(y.BlockHashes) &&
 		e.BaseFee.Cmp(y.BaseFee) == 0 &&
-		e.BlobBaseFee.Cmp(y.BlobBaseFee) == 0
+		e.BlobBaseFee.Cmp(y.BlobBaseFee) == 0 &&
+		equalHashPtr(e.PrevRandao, y.PrevRandao) &&
+		equalHashPtr(e.ParentBeaconBlockRoot, y.ParentBeaconBlockRoot) &&
+		equalUint64Ptr(e.ExcessBlobGas, y.ExcessBlobGas) &&
+		equalUint64Ptr(e.BlobGasUsed, y.BlobGasUsed) &&
+		(e.Withdrawals == nil) == (y.Withdrawals == nil) &&
+		len(e.Withdrawals) == len(y.Withdrawals)
 	if !equal {
 		return false
 	}
 
+	for i, w := range e.Withdrawals {
+		if *w != *y.Withdrawals[i] {
+			return false
+		}
+	}
+
 	for k, xv := range e.BlockHashes {
 		yv, exist := y.BlockHashes[k]
 		if !(exist && xv == yv) {
@@ -86,6 +108,24 @@ func (e *Env) String() string {
 	builder.WriteString(fmt.Sprintf("Timestamp: %v\n", e.Timestamp))
 	builder.WriteString(fmt.Sprintf("Base Fee: %v\n", e.BaseFee.String()))
 	builder.WriteString(fmt.Sprintf("Blob Base Fee: %v\n", e.BlobBaseFee.String()))
+	if e.PrevRandao != nil {
+		builder.WriteString(fmt.Sprintf("Prev Randao: %s\n", e.PrevRandao))
+	}
+	if e.ParentBeaconBlockRoot != nil {
+		builder.WriteString(fmt.Sprintf("Parent Beacon Block Root: %s\n", e.ParentBeaconBlockRoot))
+	}
+	if e.ExcessBlobGas != nil {
+		builder.WriteString(fmt.Sprintf("Excess Blob Gas: %v\n", *e.ExcessBlobGas))
+	}
+	if e.BlobGasUsed != nil {
+		builder.WriteString(fmt.Sprintf("Blob Gas Used: %v\n", *e.BlobGasUsed))
+	}
+	if e.Withdrawals != nil {
+		builder.WriteString("Withdrawals: \n")
+		for _, w := range e.Withdrawals {
+			builder.WriteString(fmt.Sprintf("%v: validator %v, %s, %v Gwei\n", w.Index, w.Validator, w.Address, w.Amount))
+		}
+	}
 	builder.WriteString("Block Hashes: \n")
 
 	for number, hash := range e.BlockHashes {
@@ -95,3 +135,17 @@ func (e *Env) String() string {
 	return builder.String()
 
 }
+
+func equalHashPtr(x, y *types.Hash) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
+
+func equalUint64Ptr(x, y *uint64) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
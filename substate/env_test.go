@@ -138,3 +138,82 @@ func TestEnv_EqualBlobBaseFee(t *testing.T) {
 		t.Fatal("envs BlobBaseFee are same but equal returned false")
 	}
 }
+
+func TestEnv_EqualPrevRandao(t *testing.T) {
+	env := &Env{
+		PrevRandao: &types.Hash{1},
+	}
+	comparedEnv := &Env{
+		PrevRandao: &types.Hash{2},
+	}
+
+	if env.Equal(comparedEnv) {
+		t.Fatal("envs PrevRandao are different but equal returned true")
+	}
+
+	comparedEnv.PrevRandao = nil
+	if env.Equal(comparedEnv) {
+		t.Fatal("envs PrevRandao are different but equal returned true")
+	}
+
+	comparedEnv.PrevRandao = &types.Hash{1}
+	if !env.Equal(comparedEnv) {
+		t.Fatal("envs PrevRandao are same but equal returned false")
+	}
+}
+
+func TestEnv_EqualBlobGas(t *testing.T) {
+	excess, used := uint64(1), uint64(2)
+	env := &Env{
+		ParentBeaconBlockRoot: &types.Hash{1},
+		ExcessBlobGas:         &excess,
+		BlobGasUsed:           &used,
+	}
+	comparedEnv := &Env{
+		ParentBeaconBlockRoot: &types.Hash{1},
+		ExcessBlobGas:         &used,
+		BlobGasUsed:           &used,
+	}
+
+	if env.Equal(comparedEnv) {
+		t.Fatal("envs ExcessBlobGas are different but equal returned true")
+	}
+
+	sameExcess := excess
+	comparedEnv.ExcessBlobGas = &sameExcess
+	if !env.Equal(comparedEnv) {
+		t.Fatal("envs blob gas are same but equal returned false")
+	}
+}
+
+func TestEnv_EqualWithdrawals(t *testing.T) {
+	env := &Env{
+		Withdrawals: []*types.Withdrawal{{Index: 1, Validator: 2, Address: types.Address{3}, Amount: 4}},
+	}
+	comparedEnv := &Env{
+		Withdrawals: []*types.Withdrawal{{Index: 1, Validator: 2, Address: types.Address{3}, Amount: 5}},
+	}
+
+	if env.Equal(comparedEnv) {
+		t.Fatal("envs Withdrawals are different but equal returned true")
+	}
+
+	comparedEnv.Withdrawals[0].Amount = 4
+	if !env.Equal(comparedEnv) {
+		t.Fatal("envs Withdrawals are same but equal returned false")
+	}
+}
+
+func TestEnv_EqualEmptyAndNilWithdrawals(t *testing.T) {
+	env := &Env{Withdrawals: []*types.Withdrawal{}}
+	comparedEnv := &Env{}
+
+	if env.Equal(comparedEnv) {
+		t.Fatal("envs Withdrawals are empty and nil but equal returned true")
+	}
+
+	comparedEnv.Withdrawals = []*types.Withdrawal{}
+	if !env.Equal(comparedEnv) {
+		t.Fatal("envs Withdrawals are both empty but equal returned false")
+	}
+}
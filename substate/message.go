@@ -35,6 +35,42 @@ type Message struct {
 	// Cancun hard fork, EIP-4844
 	BlobGasFeeCap *big.Int
 	BlobHashes    []types.Hash
+
+	// Type is the EIP-2718 type of the transaction, nil if it was not recorded.
+	// Use InferType for substates recorded without it.
+	Type *uint8
+	// ChainID is the chain ID of the transaction, nil if it was not recorded.
+	ChainID *big.Int
+	// TxHash is the hash of the recorded transaction, nil if it was not recorded.
+	TxHash *types.Hash
+}
+
+// Transaction types defined by EIP-2718.
+const (
+	LegacyTxType     uint8 = 0x00
+	AccessListTxType uint8 = 0x01
+	DynamicFeeTxType uint8 = 0x02
+	BlobTxType       uint8 = 0x03
+)
+
+// InferType returns Type if it was recorded. Otherwise, the type is inferred from fields of m.
+// Note: Dynamic fee transactions with both fee caps equal to the gas price and without
+// an access list cannot be distinguished from legacy transactions, they are reported as legacy.
+// Blob transactions are recognized by their blob hashes since decoded BlobGasFeeCap is never nil.
+func (m *Message) InferType() uint8 {
+	switch {
+	case m.Type != nil:
+		return *m.Type
+	case len(m.BlobHashes) > 0:
+		return BlobTxType
+	case m.GasPrice != nil && ((m.GasFeeCap != nil && m.GasFeeCap.Cmp(m.GasPrice) != 0) ||
+		(m.GasTipCap != nil && m.GasTipCap.Cmp(m.GasPrice) != 0)):
+		return DynamicFeeTxType
+	case len(m.AccessList) > 0:
+		return AccessListTxType
+	default:
+		return LegacyTxType
+	}
 }
 
 func NewMessage(
@@ -94,7 +130,10 @@ func (m *Message) Equal(y *Message) bool {
 		len(m.AccessList) == len(y.AccessList) &&
 		m.GasFeeCap.Cmp(y.GasFeeCap) == 0 &&
 		m.GasTipCap.Cmp(y.GasTipCap) == 0 &&
-		m.BlobGasFeeCap.Cmp(y.BlobGasFeeCap) == 0
+		m.BlobGasFeeCap.Cmp(y.BlobGasFeeCap) == 0 &&
+		equalUint8Ptr(m.Type, y.Type) &&
+		equalBig(m.ChainID, y.ChainID) &&
+		equalHashPtr(m.TxHash, y.TxHash)
 	if !equal {
 		return false
 	}
@@ -150,6 +189,15 @@ func (m *Message) String() string {
 	builder.WriteString(fmt.Sprintf("Data Hash: %s\n", m.dataHash))
 	builder.WriteString(fmt.Sprintf("Gas Fee Cap: %v\n", m.GasFeeCap.String()))
 	builder.WriteString(fmt.Sprintf("Gas Tip Cap: %v\n", m.GasTipCap.String()))
+	if m.Type != nil {
+		builder.WriteString(fmt.Sprintf("Type: %v\n", *m.Type))
+	}
+	if m.ChainID != nil {
+		builder.WriteString(fmt.Sprintf("Chain ID: %v\n", m.ChainID))
+	}
+	if m.TxHash != nil {
+		builder.WriteString(fmt.Sprintf("Tx Hash: %s\n", m.TxHash))
+	}
 
 	for _, tuple := range m.AccessList {
 		builder.WriteString(fmt.Sprintf("Address: %s", tuple.Address))
@@ -214,3 +214,44 @@ func TestMessage_DataHashGeneratesNewHashIfNil(t *testing.T) {
 	}
 
 }
+
+func TestMessage_EqualTypeChainIdAndTxHash(t *testing.T) {
+	txType := DynamicFeeTxType
+	msg := &Message{Type: &txType, ChainID: big.NewInt(1), TxHash: &types.Hash{1}}
+	comparedMsg := &Message{Type: &txType, ChainID: big.NewInt(1)}
+
+	if msg.Equal(comparedMsg) {
+		t.Fatal("messages TxHash are different but equal returned true")
+	}
+
+	comparedMsg.TxHash = &types.Hash{1}
+	if !msg.Equal(comparedMsg) {
+		t.Fatal("messages are same but equal returned false")
+	}
+
+	comparedMsg.ChainID = big.NewInt(2)
+	if msg.Equal(comparedMsg) {
+		t.Fatal("messages ChainID are different but equal returned true")
+	}
+}
+
+func TestMessage_InferType(t *testing.T) {
+	recorded := AccessListTxType
+	tests := []struct {
+		name string
+		msg  *Message
+		want uint8
+	}{
+		{"recorded", &Message{Type: &recorded, BlobHashes: []types.Hash{{1}}}, AccessListTxType},
+		{"blob", &Message{BlobGasFeeCap: big.NewInt(1), BlobHashes: []types.Hash{{1}}}, BlobTxType},
+		{"dynamic-fee", &Message{GasPrice: big.NewInt(1), GasFeeCap: big.NewInt(2), GasTipCap: big.NewInt(1)}, DynamicFeeTxType},
+		{"access-list", &Message{GasPrice: big.NewInt(1), GasFeeCap: big.NewInt(1), AccessList: types.AccessList{{}}}, AccessListTxType},
+		{"legacy", &Message{GasPrice: big.NewInt(1), GasFeeCap: big.NewInt(1), GasTipCap: big.NewInt(1)}, LegacyTxType},
+	}
+
+	for _, test := range tests {
+		if got := test.msg.InferType(); got != test.want {
+			t.Errorf("%v: unexpected type\ngot: %v\nwant: %v", test.name, got, test.want)
+		}
+	}
+}
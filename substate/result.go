@@ -3,6 +3,7 @@ package substate
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/Fantom-foundation/Substate/types"
@@ -15,6 +16,13 @@ type Result struct {
 	Logs            []*types.Log
 	ContractAddress types.Address
 	GasUsed         uint64
+
+	// Optional receipt fields, nil if they were not recorded
+	Type              *uint8   // EIP-2718 transaction type
+	CumulativeGasUsed *uint64  // gas used by the transaction and all preceding transactions of the block
+	EffectiveGasPrice *big.Int // price paid per gas after EIP-1559
+	BlobGasUsed       *uint64  // EIP-4844
+	BlobGasPrice      *big.Int // EIP-4844
 }
 
 func NewResult(status uint64, bloom types.Bloom, logs []*types.Log, contractAddress types.Address, gasUsed uint64) *Result {
@@ -42,7 +50,12 @@ func (r *Result) Equal(y *Result) bool {
 		bytes.Equal(r.Bloom[:], y.Bloom[:]) &&
 		len(r.Logs) == len(y.Logs) &&
 		r.ContractAddress == y.ContractAddress &&
-		r.GasUsed == y.GasUsed
+		r.GasUsed == y.GasUsed &&
+		equalUint8Ptr(r.Type, y.Type) &&
+		equalUint64Ptr(r.CumulativeGasUsed, y.CumulativeGasUsed) &&
+		equalBig(r.EffectiveGasPrice, y.EffectiveGasPrice) &&
+		equalUint64Ptr(r.BlobGasUsed, y.BlobGasUsed) &&
+		equalBig(r.BlobGasPrice, y.BlobGasPrice)
 	if !equal {
 		return false
 	}
@@ -75,6 +88,21 @@ func (r *Result) String() string {
 	builder.WriteString(fmt.Sprintf("Bloom: %s", r.Bloom))
 	builder.WriteString(fmt.Sprintf("Contract Address: %s", r.ContractAddress))
 	builder.WriteString(fmt.Sprintf("Gas Used: %v", r.GasUsed))
+	if r.Type != nil {
+		builder.WriteString(fmt.Sprintf("Type: %v", *r.Type))
+	}
+	if r.CumulativeGasUsed != nil {
+		builder.WriteString(fmt.Sprintf("Cumulative Gas Used: %v", *r.CumulativeGasUsed))
+	}
+	if r.EffectiveGasPrice != nil {
+		builder.WriteString(fmt.Sprintf("Effective Gas Price: %v", r.EffectiveGasPrice))
+	}
+	if r.BlobGasUsed != nil {
+		builder.WriteString(fmt.Sprintf("Blob Gas Used: %v", *r.BlobGasUsed))
+	}
+	if r.BlobGasPrice != nil {
+		builder.WriteString(fmt.Sprintf("Blob Gas Price: %v", r.BlobGasPrice))
+	}
 
 	for _, log := range r.Logs {
 		builder.WriteString(fmt.Sprintf("%v", *log))
@@ -82,3 +110,18 @@ func (r *Result) String() string {
 
 	return builder.String()
 }
+
+func equalUint8Ptr(x, y *uint8) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return *x == *y
+}
+
+// equalBig returns true if x and y are both nil or both hold the same value.
+func equalBig(x, y *big.Int) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Cmp(y) == 0
+}
@@ -1,6 +1,7 @@
 package substate
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/Fantom-foundation/Substate/types"
@@ -75,3 +76,24 @@ func TestAccount_EqualGasUsed(t *testing.T) {
 		t.Fatal("results GasUsed are same but equal returned false")
 	}
 }
+
+func TestResult_EqualReceiptFields(t *testing.T) {
+	txType, cumulativeGasUsed := uint8(2), uint64(100)
+	res := &Result{Type: &txType, CumulativeGasUsed: &cumulativeGasUsed, EffectiveGasPrice: big.NewInt(1)}
+	comparedRes := &Result{Type: &txType, CumulativeGasUsed: &cumulativeGasUsed}
+
+	if res.Equal(comparedRes) {
+		t.Fatal("results EffectiveGasPrice are different but equal returned true")
+	}
+
+	comparedRes.EffectiveGasPrice = big.NewInt(1)
+	if !res.Equal(comparedRes) {
+		t.Fatal("results receipt fields are same but equal returned false")
+	}
+
+	otherType := uint8(1)
+	comparedRes.Type = &otherType
+	if res.Equal(comparedRes) {
+		t.Fatal("results Type are different but equal returned true")
+	}
+}
@@ -0,0 +1,72 @@
+package trie
+
+// Keys are stored within the trie as nibbles (hex encoding). Keys of values
+// are terminated by the terminator nibble, which allows values to be stored in branch nodes.
+// Keys of encoded nodes use the compact (hex-prefix) encoding defined by the Yellow Paper.
+
+const terminator = 16
+
+// keybytesToHex returns nibbles of key followed by the terminator.
+func keybytesToHex(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2+1)
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[len(nibbles)-1] = terminator
+	return nibbles
+}
+
+// hexToCompact returns compact encoding of nibbles. The terminator is turned into a flag.
+func hexToCompact(hex []byte) []byte {
+	var flag byte
+	if hasTerm(hex) {
+		flag = 1 << 5
+		hex = hex[:len(hex)-1]
+	}
+
+	compact := make([]byte, len(hex)/2+1)
+	compact[0] = flag
+	if len(hex)&1 == 1 {
+		compact[0] |= 1<<4 | hex[0]
+		hex = hex[1:]
+	}
+	for i := 0; i < len(hex); i += 2 {
+		compact[i/2+1] = hex[i]<<4 | hex[i+1]
+	}
+	return compact
+}
+
+// compactToHex returns nibbles of compact encoded key. Keys flagged as leaves get the terminator.
+func compactToHex(compact []byte) []byte {
+	if len(compact) == 0 {
+		return compact
+	}
+
+	nibbles := make([]byte, 0, len(compact)*2+1)
+	for _, b := range compact {
+		nibbles = append(nibbles, b/16, b%16)
+	}
+
+	// skip flag nibble and the padding nibble of even keys
+	start := 2 - nibbles[0]&1
+	nibbles = nibbles[start:]
+	if compact[0]&(1<<5) != 0 {
+		nibbles = append(nibbles, terminator)
+	}
+	return nibbles
+}
+
+// prefixLen returns the length of the common prefix of a and b.
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// hasTerm returns true if nibbles are terminated by the terminator.
+func hasTerm(nibbles []byte) bool {
+	return len(nibbles) > 0 && nibbles[len(nibbles)-1] == terminator
+}
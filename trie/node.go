@@ -0,0 +1,89 @@
+package trie
+
+import (
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+// hashLength is the length of node references which are not embedded.
+const hashLength = 32
+
+type (
+	// node is one of *fullNode, *shortNode or valueNode.
+	// Nodes are never modified once created, hence their references can be cached.
+	node interface{}
+
+	// fullNode is a branch node with a child for every nibble and an optional value.
+	fullNode struct {
+		Children [17]node
+		ref      rlp.RawValue
+	}
+
+	// shortNode is an extension node if Val is a fullNode, or a leaf node if Val is a valueNode.
+	shortNode struct {
+		Key []byte // nibbles, terminated for leaf nodes
+		Val node
+		ref rlp.RawValue
+	}
+
+	valueNode []byte
+)
+
+func (n *fullNode) copy() *fullNode {
+	return &fullNode{Children: n.Children}
+}
+
+// encodeNode returns the RLP encoding of n.
+// Children are embedded if their encoding is shorter than 32 bytes, otherwise they are referenced by hash.
+func encodeNode(n node) []byte {
+	var items []interface{}
+	switch n := n.(type) {
+	case *shortNode:
+		items = []interface{}{hexToCompact(n.Key), childRef(n.Val)}
+	case *fullNode:
+		items = make([]interface{}, len(n.Children))
+		for i, child := range n.Children {
+			items[i] = childRef(child)
+		}
+	default:
+		panic("cannot encode value node")
+	}
+
+	enc, err := rlp.EncodeToBytes(items)
+	if err != nil {
+		// encoding of byte strings and raw values cannot fail
+		panic(err)
+	}
+	return enc
+}
+
+// childRef returns the RLP value used to reference n from its parent.
+func childRef(n node) rlp.RawValue {
+	switch n := n.(type) {
+	case nil:
+		return rlp.RawValue{0x80}
+	case valueNode:
+		enc, _ := rlp.EncodeToBytes([]byte(n))
+		return enc
+	case *shortNode:
+		if n.ref == nil {
+			n.ref = refOf(encodeNode(n))
+		}
+		return n.ref
+	case *fullNode:
+		if n.ref == nil {
+			n.ref = refOf(encodeNode(n))
+		}
+		return n.ref
+	}
+	panic("unknown node type")
+}
+
+// refOf returns reference of a node with given encoding.
+func refOf(enc []byte) rlp.RawValue {
+	if len(enc) < 32 {
+		return enc
+	}
+	h := hash.Keccak256Hash(enc)
+	return append(rlp.RawValue{0x80 + hashLength}, h[:]...)
+}
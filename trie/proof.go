@@ -0,0 +1,127 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+// Prove returns the Merkle proof of key. The proof contains encodings of all nodes
+// on the path to key which are referenced by hash, starting with the root node.
+// If key is not present, the proof shows its absence.
+func (t *Trie) Prove(key []byte) [][]byte {
+	var proof [][]byte
+
+	n := t.root
+	k := keybytesToHex(key)
+	for n != nil {
+		if _, isValue := n.(valueNode); isValue {
+			break
+		}
+
+		// embedded nodes are part of their parent's encoding
+		if len(proof) == 0 || len(childRef(n)) == 1+hashLength {
+			proof = append(proof, encodeNode(n))
+		}
+
+		switch cur := n.(type) {
+		case *shortNode:
+			if len(k) < len(cur.Key) || !bytes.Equal(cur.Key, k[:len(cur.Key)]) {
+				return proof
+			}
+			n, k = cur.Val, k[len(cur.Key):]
+		case *fullNode:
+			n, k = cur.Children[k[0]], k[1:]
+		}
+	}
+	return proof
+}
+
+// VerifyProof checks the Merkle proof of key against the root hash and returns value of key.
+// Nil value and nil error are returned if the proof shows that key is not present.
+func VerifyProof(root types.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	if root == EmptyRootHash {
+		return nil, nil
+	}
+
+	nodes := make(map[types.Hash][]byte, len(proof))
+	for _, enc := range proof {
+		nodes[hash.Keccak256Hash(enc)] = enc
+	}
+
+	k := keybytesToHex(key)
+	ref := append(rlp.RawValue{0x80 + hashLength}, root[:]...)
+	for {
+		enc, err := resolveRef(ref, nodes)
+		if err != nil {
+			return nil, err
+		}
+		if enc == nil {
+			return nil, nil
+		}
+
+		var items []rlp.RawValue
+		if err = rlp.DecodeBytes(enc, &items); err != nil {
+			return nil, fmt.Errorf("cannot decode proof node; %w", err)
+		}
+
+		switch len(items) {
+		case 2:
+			var compact []byte
+			if err = rlp.DecodeBytes(items[0], &compact); err != nil {
+				return nil, fmt.Errorf("cannot decode key of proof node; %w", err)
+			}
+			nibbles := compactToHex(compact)
+			if len(k) < len(nibbles) || !bytes.Equal(nibbles, k[:len(nibbles)]) {
+				return nil, nil
+			}
+			k = k[len(nibbles):]
+			if hasTerm(nibbles) {
+				return decodeValue(items[1])
+			}
+			ref = items[1]
+
+		case 17:
+			if k[0] == terminator {
+				return decodeValue(items[terminator])
+			}
+			ref, k = items[k[0]], k[1:]
+
+		default:
+			return nil, fmt.Errorf("invalid proof node with %v items", len(items))
+		}
+	}
+}
+
+// resolveRef returns encoding of a node referenced by ref or nil if ref is empty.
+func resolveRef(ref rlp.RawValue, nodes map[types.Hash][]byte) ([]byte, error) {
+	switch {
+	case len(ref) == 1 && ref[0] == 0x80:
+		return nil, nil
+	case len(ref) > 0 && ref[0] >= 0xc0:
+		// embedded node
+		return ref, nil
+	case len(ref) == 1+hashLength && ref[0] == 0x80+hashLength:
+		enc, found := nodes[types.BytesToHash(ref[1:])]
+		if !found {
+			return nil, fmt.Errorf("missing proof node %x", ref[1:])
+		}
+		return enc, nil
+	}
+	return nil, errors.New("invalid node reference")
+}
+
+func decodeValue(enc rlp.RawValue) ([]byte, error) {
+	var value []byte
+	if err := rlp.DecodeBytes(enc, &value); err != nil {
+		return nil, fmt.Errorf("cannot decode value of proof node; %w", err)
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	return value, nil
+}
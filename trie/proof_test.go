@@ -0,0 +1,62 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrie_ProveAndVerify(t *testing.T) {
+	trie := New()
+	kvs := map[string]string{
+		"doe":          "reindeer",
+		"dog":          "puppy",
+		"dogglesworth": "cat",
+		"horse":        "stallion",
+	}
+	for k, v := range kvs {
+		trie.Update([]byte(k), []byte(v))
+	}
+	root := trie.Hash()
+
+	for k, v := range kvs {
+		value, err := VerifyProof(root, []byte(k), trie.Prove([]byte(k)))
+		if err != nil {
+			t.Fatalf("cannot verify proof of %v; %v", k, err)
+		}
+		if !bytes.Equal(value, []byte(v)) {
+			t.Fatalf("unexpected value of %v\ngot: %s\nwant: %s", k, value, v)
+		}
+	}
+
+	for _, k := range []string{"do", "dogs", "cat", ""} {
+		value, err := VerifyProof(root, []byte(k), trie.Prove([]byte(k)))
+		if err != nil {
+			t.Fatalf("cannot verify absence proof of %v; %v", k, err)
+		}
+		if value != nil {
+			t.Fatalf("absent key %v must not have a value, got: %s", k, value)
+		}
+	}
+}
+
+func TestVerifyProof_FailsOnMissingNode(t *testing.T) {
+	trie := New()
+	trie.Update([]byte("doe"), []byte("reindeer"))
+	trie.Update([]byte("dog"), []byte("puppy"))
+	trie.Update([]byte("dogglesworth"), []byte("cat"))
+
+	proof := trie.Prove([]byte("dogglesworth"))
+	if len(proof) < 2 {
+		t.Fatalf("unexpected proof length %v", len(proof))
+	}
+
+	if _, err := VerifyProof(trie.Hash(), []byte("dogglesworth"), proof[:len(proof)-1]); err == nil {
+		t.Fatal("verification of incomplete proof must fail")
+	}
+
+	proof[0] = append([]byte{}, proof[0]...)
+	proof[0][len(proof[0])-1]++
+	if _, err := VerifyProof(trie.Hash(), []byte("dogglesworth"), proof); err == nil {
+		t.Fatal("verification of modified proof must fail")
+	}
+}
@@ -0,0 +1,75 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+const (
+	receiptStatusFailed     = 0
+	receiptStatusSuccessful = 1
+)
+
+// receiptRLP is the consensus encoding of a receipt.
+type receiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	Logs              []*types.Log
+}
+
+// EncodeReceipt returns the consensus encoding of the receipt of r with given cumulative gas used.
+// Typed receipts (EIP-2718) are prefixed by their type.
+func EncodeReceipt(r *substate.Result, cumulativeGasUsed uint64) ([]byte, error) {
+	var status []byte
+	switch r.Status {
+	case receiptStatusFailed:
+	case receiptStatusSuccessful:
+		status = []byte{receiptStatusSuccessful}
+	default:
+		return nil, fmt.Errorf("invalid receipt status %v", r.Status)
+	}
+
+	logs := r.Logs
+	if logs == nil {
+		logs = []*types.Log{}
+	}
+	enc, err := rlp.EncodeToBytes(&receiptRLP{status, cumulativeGasUsed, r.Bloom, logs})
+	if err != nil {
+		return nil, err
+	}
+	if r.Type == nil || *r.Type == 0 {
+		return enc, nil
+	}
+	return append([]byte{*r.Type}, enc...), nil
+}
+
+// ReceiptsRoot returns root hash of the receipts trie of results ordered by transaction index.
+// If CumulativeGasUsed of a result was not recorded, it is derived from GasUsed of the result
+// and cumulative gas of the preceding one, so results of all transactions of the block are required.
+func ReceiptsRoot(results []*substate.Result) (types.Hash, error) {
+	t := New()
+
+	var cumulativeGasUsed uint64
+	for i, r := range results {
+		if r.CumulativeGasUsed != nil {
+			cumulativeGasUsed = *r.CumulativeGasUsed
+		} else {
+			cumulativeGasUsed += r.GasUsed
+		}
+
+		enc, err := EncodeReceipt(r, cumulativeGasUsed)
+		if err != nil {
+			return types.Hash{}, fmt.Errorf("cannot encode receipt %v; %w", i, err)
+		}
+		key, err := rlp.EncodeToBytes(uint(i))
+		if err != nil {
+			return types.Hash{}, err
+		}
+		t.Update(key, enc)
+	}
+	return t.Hash(), nil
+}
@@ -0,0 +1,67 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestEncodeReceipt_Typed(t *testing.T) {
+	r := substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000)
+	legacy, err := EncodeReceipt(r, 21_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txType := uint8(2)
+	r.Type = &txType
+	typed, err := EncodeReceipt(r, 21_000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typed[0] != 2 || !bytes.Equal(typed[1:], legacy) {
+		t.Fatalf("typed receipt must be prefixed by its type\ngot: %x\nlegacy: %x", typed, legacy)
+	}
+
+	r.Status = 2
+	if _, err = EncodeReceipt(r, 21_000); err == nil {
+		t.Fatal("invalid status must be rejected")
+	}
+}
+
+func TestReceiptsRoot_DerivesCumulativeGas(t *testing.T) {
+	if root, err := ReceiptsRoot(nil); err != nil || root != EmptyRootHash {
+		t.Fatalf("unexpected root of no receipts %v; %v", root, err)
+	}
+
+	derived := []*substate.Result{
+		substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000),
+		substate.NewResult(0, types.Bloom{}, []*types.Log{{Address: types.Address{1}}}, types.Address{}, 30_000),
+	}
+	first, second := uint64(21_000), uint64(51_000)
+	recorded := []*substate.Result{
+		substate.NewResult(1, types.Bloom{}, nil, types.Address{}, 21_000),
+		substate.NewResult(0, types.Bloom{}, []*types.Log{{Address: types.Address{1}}}, types.Address{}, 30_000),
+	}
+	recorded[0].CumulativeGasUsed = &first
+	recorded[1].CumulativeGasUsed = &second
+
+	want, err := ReceiptsRoot(recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReceiptsRoot(derived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatalf("unexpected root\ngot: %v\nwant: %v", got, want)
+	}
+
+	second = 50_000
+	if changed, _ := ReceiptsRoot(recorded); changed == want {
+		t.Fatal("root must depend on cumulative gas used")
+	}
+}
@@ -0,0 +1,80 @@
+package trie
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+// StateAccount is the consensus representation of an account stored within the state trie.
+type StateAccount struct {
+	Nonce       uint64
+	Balance     *big.Int
+	StorageRoot types.Hash
+	CodeHash    types.Hash
+}
+
+// NewStateAccount returns StateAccount representing acc.
+func NewStateAccount(acc *substate.Account) StateAccount {
+	balance := acc.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return StateAccount{
+		Nonce:       acc.Nonce,
+		Balance:     balance,
+		StorageRoot: StorageRoot(acc),
+		CodeHash:    acc.CodeHash(),
+	}
+}
+
+// NewStateTrie returns account trie of ws. Accounts are keyed by Keccak256 hash of their address.
+func NewStateTrie(ws substate.WorldState) *Trie {
+	t := New()
+	for addr, acc := range ws {
+		value, err := rlp.EncodeToBytes(NewStateAccount(acc))
+		if err != nil {
+			// encoding of integers and hashes cannot fail
+			panic(err)
+		}
+		t.Update(hash.Keccak256Hash(addr[:]).Bytes(), value)
+	}
+	return t
+}
+
+// NewStorageTrie returns storage trie of acc. Slots are keyed by Keccak256 hash of their key,
+// values are RLP encoded without leading zeros. Zero values are not stored.
+func NewStorageTrie(acc *substate.Account) *Trie {
+	t := New()
+	for key, value := range acc.Storage {
+		trimmed := bytes.TrimLeft(value[:], "\x00")
+		if len(trimmed) == 0 {
+			continue
+		}
+		enc, err := rlp.EncodeToBytes(trimmed)
+		if err != nil {
+			// encoding of byte strings cannot fail
+			panic(err)
+		}
+		t.Update(hash.Keccak256Hash(key[:]).Bytes(), enc)
+	}
+	return t
+}
+
+// StateRoot returns root hash of the account trie of ws.
+// Note: The root equals the block state root only if ws contains the complete world state.
+func StateRoot(ws substate.WorldState) types.Hash {
+	return NewStateTrie(ws).Hash()
+}
+
+// StorageRoot returns root hash of the storage trie of acc.
+func StorageRoot(acc *substate.Account) types.Hash {
+	if len(acc.Storage) == 0 {
+		return EmptyRootHash
+	}
+	return NewStorageTrie(acc).Hash()
+}
@@ -0,0 +1,132 @@
+package trie
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+var emptyCodeHash = hash.Keccak256Hash(nil)
+
+// AccountResult is the proof of an account and its storage slots as returned by eth_getProof.
+type AccountResult struct {
+	Address      types.Address
+	AccountProof [][]byte
+	Balance      *big.Int
+	CodeHash     types.Hash
+	Nonce        uint64
+	StorageHash  types.Hash
+	StorageProof []StorageResult
+}
+
+// StorageResult is the proof of a storage slot as returned by eth_getProof.
+type StorageResult struct {
+	Key   types.Hash
+	Value types.Hash
+	Proof [][]byte
+}
+
+// StateProver creates proofs of accounts and storage slots of a world state.
+// The world state must not be modified while it is used by the StateProver.
+type StateProver struct {
+	ws       substate.WorldState
+	accounts *Trie
+	storages map[types.Address]*Trie
+}
+
+// NewStateProver builds the account trie of ws.
+func NewStateProver(ws substate.WorldState) *StateProver {
+	return &StateProver{
+		ws:       ws,
+		accounts: NewStateTrie(ws),
+		storages: make(map[types.Address]*Trie),
+	}
+}
+
+// Root returns the state root of the world state.
+func (p *StateProver) Root() types.Hash {
+	return p.accounts.Hash()
+}
+
+// GetProof returns proof of account addr and its storage slots keys.
+// Proof of a missing account shows its absence and carries values of an empty account.
+func (p *StateProver) GetProof(addr types.Address, keys []types.Hash) *AccountResult {
+	res := &AccountResult{
+		Address:      addr,
+		AccountProof: p.accounts.Prove(hash.Keccak256Hash(addr[:]).Bytes()),
+		Balance:      new(big.Int),
+		CodeHash:     emptyCodeHash,
+		StorageHash:  EmptyRootHash,
+		StorageProof: make([]StorageResult, 0, len(keys)),
+	}
+
+	acc, found := p.ws[addr]
+	if !found {
+		for _, key := range keys {
+			res.StorageProof = append(res.StorageProof, StorageResult{Key: key})
+		}
+		return res
+	}
+
+	storage, found := p.storages[addr]
+	if !found {
+		storage = NewStorageTrie(acc)
+		p.storages[addr] = storage
+	}
+
+	state := NewStateAccount(acc)
+	res.Balance = state.Balance
+	res.CodeHash = state.CodeHash
+	res.Nonce = state.Nonce
+	res.StorageHash = state.StorageRoot
+	for _, key := range keys {
+		res.StorageProof = append(res.StorageProof, StorageResult{
+			Key:   key,
+			Value: acc.Storage[key],
+			Proof: storage.Prove(hash.Keccak256Hash(key[:]).Bytes()),
+		})
+	}
+	return res
+}
+
+// VerifyAccountProof checks the account proof and all storage proofs of res against the state root.
+func VerifyAccountProof(root types.Hash, res *AccountResult) error {
+	value, err := VerifyProof(root, hash.Keccak256Hash(res.Address[:]).Bytes(), res.AccountProof)
+	if err != nil {
+		return fmt.Errorf("invalid proof of account %s; %w", res.Address, err)
+	}
+
+	want := StateAccount{Balance: new(big.Int), StorageRoot: EmptyRootHash, CodeHash: emptyCodeHash}
+	if value != nil {
+		if err = rlp.DecodeBytes(value, &want); err != nil {
+			return fmt.Errorf("cannot decode account %s; %w", res.Address, err)
+		}
+	}
+
+	if res.Nonce != want.Nonce || res.Balance == nil || res.Balance.Cmp(want.Balance) != 0 ||
+		res.StorageHash != want.StorageRoot || res.CodeHash != want.CodeHash {
+		return fmt.Errorf("account %s does not match its proof", res.Address)
+	}
+
+	for _, slot := range res.StorageProof {
+		value, err = VerifyProof(res.StorageHash, hash.Keccak256Hash(slot.Key[:]).Bytes(), slot.Proof)
+		if err != nil {
+			return fmt.Errorf("invalid proof of slot %s of account %s; %w", slot.Key, res.Address, err)
+		}
+
+		var stored []byte
+		if value != nil {
+			if err = rlp.DecodeBytes(value, &stored); err != nil {
+				return fmt.Errorf("cannot decode slot %s of account %s; %w", slot.Key, res.Address, err)
+			}
+		}
+		if types.BytesToHash(stored) != slot.Value {
+			return fmt.Errorf("slot %s of account %s does not match its proof", slot.Key, res.Address)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package trie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestStateProver_GetProof(t *testing.T) {
+	ws := substate.NewWorldState().
+		Add(types.Address{1}, 1, big.NewInt(100), nil).
+		Add(types.Address{2}, 0, big.NewInt(0), []byte{0x60, 0x00})
+	ws[types.Address{2}].Storage[types.Hash{1}] = types.Hash{2}
+	ws[types.Address{2}].Storage[types.Hash{3}] = types.Hash{4}
+
+	p := NewStateProver(ws)
+	root := p.Root()
+	if root != StateRoot(ws) {
+		t.Fatalf("unexpected root\ngot: %v\nwant: %v", root, StateRoot(ws))
+	}
+
+	res := p.GetProof(types.Address{2}, []types.Hash{{1}, {5}})
+	if err := VerifyAccountProof(root, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.StorageProof[0].Value != (types.Hash{2}) || res.StorageProof[1].Value != (types.Hash{}) {
+		t.Fatalf("unexpected storage values: %v", res.StorageProof)
+	}
+
+	// missing account
+	res = p.GetProof(types.Address{3}, []types.Hash{{1}})
+	if err := VerifyAccountProof(root, res); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAccountProof_DetectsModifiedValues(t *testing.T) {
+	ws := substate.NewWorldState().Add(types.Address{1}, 1, big.NewInt(100), nil)
+	ws[types.Address{1}].Storage[types.Hash{1}] = types.Hash{2}
+
+	p := NewStateProver(ws)
+
+	res := p.GetProof(types.Address{1}, nil)
+	res.Balance = big.NewInt(101)
+	if err := VerifyAccountProof(p.Root(), res); err == nil {
+		t.Fatal("verification of modified balance must fail")
+	}
+
+	res = p.GetProof(types.Address{1}, []types.Hash{{1}})
+	res.StorageProof[0].Value = types.Hash{3}
+	if err := VerifyAccountProof(p.Root(), res); err == nil {
+		t.Fatal("verification of modified storage must fail")
+	}
+}
@@ -0,0 +1,49 @@
+package trie
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestStorageRoot_IgnoresZeroValues(t *testing.T) {
+	acc := substate.NewAccount(1, big.NewInt(1), nil)
+	if got := StorageRoot(acc); got != EmptyRootHash {
+		t.Fatalf("unexpected storage root of empty storage\ngot: %v\nwant: %v", got, EmptyRootHash)
+	}
+
+	acc.Storage[types.Hash{1}] = types.Hash{}
+	if got := StorageRoot(acc); got != EmptyRootHash {
+		t.Fatalf("zero values must not be stored\ngot: %v\nwant: %v", got, EmptyRootHash)
+	}
+
+	acc.Storage[types.Hash{2}] = types.BytesToHash([]byte{1})
+	if got := StorageRoot(acc); got == EmptyRootHash {
+		t.Fatal("storage root must change")
+	}
+}
+
+func TestStateRoot(t *testing.T) {
+	ws := substate.NewWorldState().
+		Add(types.Address{1}, 1, big.NewInt(100), nil).
+		Add(types.Address{2}, 0, big.NewInt(0), []byte{0x60, 0x00})
+
+	root := StateRoot(ws)
+	if root == EmptyRootHash {
+		t.Fatal("state root must not be empty")
+	}
+
+	// root depends on content only
+	cp := substate.NewWorldState()
+	cp.Merge(ws)
+	if got := StateRoot(cp); got != root {
+		t.Fatalf("unexpected state root of copy\ngot: %v\nwant: %v", got, root)
+	}
+
+	cp[types.Address{2}].Storage[types.Hash{1}] = types.Hash{1}
+	if got := StateRoot(cp); got == root {
+		t.Fatal("state root must reflect storage changes")
+	}
+}
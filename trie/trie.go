@@ -0,0 +1,170 @@
+package trie
+
+import (
+	"bytes"
+
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/hash"
+)
+
+// EmptyRootHash is the root hash of an empty trie.
+var EmptyRootHash = hash.Keccak256Hash([]byte{0x80})
+
+// Trie is an in-memory Merkle Patricia Trie as defined by the Ethereum Yellow Paper.
+// Trie is not safe for concurrent use.
+type Trie struct {
+	root node
+}
+
+// New creates an empty Trie.
+func New() *Trie {
+	return &Trie{}
+}
+
+// Get returns value stored under key or nil if key is not present.
+func (t *Trie) Get(key []byte) []byte {
+	n := t.root
+	k := keybytesToHex(key)
+	for {
+		switch cur := n.(type) {
+		case nil:
+			return nil
+		case valueNode:
+			return cur
+		case *shortNode:
+			if len(k) < len(cur.Key) || !bytes.Equal(cur.Key, k[:len(cur.Key)]) {
+				return nil
+			}
+			n, k = cur.Val, k[len(cur.Key):]
+		case *fullNode:
+			n, k = cur.Children[k[0]], k[1:]
+		}
+	}
+}
+
+// Update stores value under key. Empty value deletes the key from the trie.
+func (t *Trie) Update(key, value []byte) {
+	k := keybytesToHex(key)
+	if len(value) == 0 {
+		t.root = remove(t.root, k)
+		return
+	}
+	v := make(valueNode, len(value))
+	copy(v, value)
+	t.root = insert(t.root, k, v)
+}
+
+// Delete removes key from the trie.
+func (t *Trie) Delete(key []byte) {
+	t.root = remove(t.root, keybytesToHex(key))
+}
+
+// Hash returns the root hash of the trie.
+func (t *Trie) Hash() types.Hash {
+	if t.root == nil {
+		return EmptyRootHash
+	}
+	return hash.Keccak256Hash(encodeNode(t.root))
+}
+
+func insert(n node, key []byte, value valueNode) node {
+	if len(key) == 0 {
+		return value
+	}
+
+	switch n := n.(type) {
+	case nil:
+		return &shortNode{Key: key, Val: value}
+
+	case *shortNode:
+		matchLen := prefixLen(key, n.Key)
+		if matchLen == len(n.Key) {
+			return &shortNode{Key: n.Key, Val: insert(n.Val, key[matchLen:], value)}
+		}
+
+		// keys differ, a branch is placed where they split
+		branch := &fullNode{}
+		branch.Children[n.Key[matchLen]] = insertNode(n.Key[matchLen+1:], n.Val)
+		branch.Children[key[matchLen]] = insert(nil, key[matchLen+1:], value)
+		if matchLen == 0 {
+			return branch
+		}
+		return &shortNode{Key: key[:matchLen], Val: branch}
+
+	case *fullNode:
+		branch := n.copy()
+		branch.Children[key[0]] = insert(n.Children[key[0]], key[1:], value)
+		return branch
+	}
+	panic("unknown node type")
+}
+
+// insertNode returns child n placed under remaining key.
+func insertNode(key []byte, n node) node {
+	if len(key) == 0 {
+		return n
+	}
+	return &shortNode{Key: key, Val: n}
+}
+
+func remove(n node, key []byte) node {
+	switch n := n.(type) {
+	case nil, valueNode:
+		return nil
+
+	case *shortNode:
+		matchLen := prefixLen(key, n.Key)
+		if matchLen < len(n.Key) {
+			// key is not present
+			return n
+		}
+		if matchLen == len(key) {
+			return nil
+		}
+
+		child := remove(n.Val, key[matchLen:])
+		if child == nil {
+			return nil
+		}
+		if short, ok := child.(*shortNode); ok {
+			// merge extension with its child
+			return &shortNode{Key: concat(n.Key, short.Key...), Val: short.Val}
+		}
+		return &shortNode{Key: n.Key, Val: child}
+
+	case *fullNode:
+		if n.Children[key[0]] == nil {
+			return n
+		}
+		branch := n.copy()
+		branch.Children[key[0]] = remove(n.Children[key[0]], key[1:])
+
+		pos := -1
+		for i, child := range branch.Children {
+			if child == nil {
+				continue
+			}
+			if pos >= 0 {
+				// at least two children remain
+				return branch
+			}
+			pos = i
+		}
+
+		// a single child remains, the branch is replaced by a short node
+		if pos == terminator {
+			return &shortNode{Key: []byte{terminator}, Val: branch.Children[pos]}
+		}
+		if short, ok := branch.Children[pos].(*shortNode); ok {
+			return &shortNode{Key: concat([]byte{byte(pos)}, short.Key...), Val: short.Val}
+		}
+		return &shortNode{Key: []byte{byte(pos)}, Val: branch.Children[pos]}
+	}
+	panic("unknown node type")
+}
+
+func concat(a []byte, b ...byte) []byte {
+	c := make([]byte, 0, len(a)+len(b))
+	c = append(c, a...)
+	return append(c, b...)
+}
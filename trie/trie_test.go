@@ -0,0 +1,89 @@
+package trie
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func TestTrie_EmptyRoot(t *testing.T) {
+	want := types.BytesToHash(types.Hex2Bytes("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421"))
+	if got := New().Hash(); got != want {
+		t.Fatalf("unexpected root\ngot: %v\nwant: %v", got, want)
+	}
+}
+
+func TestTrie_Hash(t *testing.T) {
+	tests := []struct {
+		name string
+		kvs  [][2]string
+		root string
+	}{
+		{
+			name: "dogs",
+			kvs:  [][2]string{{"doe", "reindeer"}, {"dog", "puppy"}, {"dogglesworth", "cat"}},
+			root: "8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3",
+		},
+		{
+			name: "deletes",
+			kvs: [][2]string{
+				{"do", "verb"}, {"ether", "wookiedoo"}, {"horse", "stallion"}, {"shaman", "horse"},
+				{"doge", "coin"}, {"ether", ""}, {"dog", "puppy"}, {"shaman", ""},
+			},
+			root: "5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			trie := New()
+			for _, kv := range test.kvs {
+				trie.Update([]byte(kv[0]), []byte(kv[1]))
+			}
+
+			want := types.BytesToHash(types.Hex2Bytes(test.root))
+			if got := trie.Hash(); got != want {
+				t.Fatalf("unexpected root\ngot: %v\nwant: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestTrie_Get(t *testing.T) {
+	trie := New()
+	trie.Update([]byte("doe"), []byte("reindeer"))
+	trie.Update([]byte("dog"), []byte("puppy"))
+	trie.Update([]byte("dogglesworth"), []byte("cat"))
+	trie.Delete([]byte("doe"))
+
+	if got := trie.Get([]byte("dog")); !bytes.Equal(got, []byte("puppy")) {
+		t.Fatalf("unexpected value\ngot: %s\nwant: puppy", got)
+	}
+	if got := trie.Get([]byte("doe")); got != nil {
+		t.Fatalf("deleted key must not be found, got: %s", got)
+	}
+	if got := trie.Get([]byte("do")); got != nil {
+		t.Fatalf("missing key must not be found, got: %s", got)
+	}
+}
+
+func TestHexToCompact(t *testing.T) {
+	tests := []struct{ hex, compact []byte }{
+		{hex: []byte{}, compact: []byte{0x00}},
+		{hex: []byte{terminator}, compact: []byte{0x20}},
+		{hex: []byte{1, 2, 3, 4, 5}, compact: []byte{0x11, 0x23, 0x45}},
+		{hex: []byte{0, 1, 2, 3, 4, 5}, compact: []byte{0x00, 0x01, 0x23, 0x45}},
+		{hex: []byte{15, 1, 12, 11, 8, terminator}, compact: []byte{0x3f, 0x1c, 0xb8}},
+		{hex: []byte{0, 15, 1, 12, 11, 8, terminator}, compact: []byte{0x20, 0x0f, 0x1c, 0xb8}},
+	}
+
+	for _, test := range tests {
+		if got := hexToCompact(test.hex); !bytes.Equal(got, test.compact) {
+			t.Errorf("unexpected compact encoding of %x\ngot: %x\nwant: %x", test.hex, got, test.compact)
+		}
+		if got := compactToHex(test.compact); !bytes.Equal(got, test.hex) {
+			t.Errorf("unexpected hex encoding of %x\ngot: %x\nwant: %x", test.compact, got, test.hex)
+		}
+	}
+}
@@ -1,6 +1,10 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
 
 const (
 	// BloomByteLength represents the number of bytes used in a header log bloom.
@@ -33,3 +37,48 @@ func (b *Bloom) SetBytes(d []byte) {
 func (b Bloom) Bytes() []byte {
 	return b[:]
 }
+
+// Add adds d to the filter. Future calls of Test(d) will return true.
+func (b *Bloom) Add(d []byte) {
+	i1, v1, i2, v2, i3, v3 := bloomValues(d)
+	b[i1] |= v1
+	b[i2] |= v2
+	b[i3] |= v3
+}
+
+// Test checks if the given topic is present in the bloom filter.
+func (b Bloom) Test(topic []byte) bool {
+	i1, v1, i2, v2, i3, v3 := bloomValues(topic)
+	return v1 == v1&b[i1] &&
+		v2 == v2&b[i2] &&
+		v3 == v3&b[i3]
+}
+
+// LogsBloom returns the bloom filter of given logs.
+func LogsBloom(logs []*Log) Bloom {
+	var b Bloom
+	for _, log := range logs {
+		b.Add(log.Address.Bytes())
+		for _, topic := range log.Topics {
+			b.Add(topic[:])
+		}
+	}
+	return b
+}
+
+// bloomValues returns the bytes (index-value pairs) to set for the given data.
+func bloomValues(data []byte) (uint, byte, uint, byte, uint, byte) {
+	sha := sha3.NewLegacyKeccak256()
+	sha.Write(data)
+	hashBuf := sha.Sum(nil)
+	// The actual bits to flip
+	v1 := byte(1 << (hashBuf[1] & 0x7))
+	v2 := byte(1 << (hashBuf[3] & 0x7))
+	v3 := byte(1 << (hashBuf[5] & 0x7))
+	// The indices for the bytes to OR in
+	i1 := BloomByteLength - uint((uint16(hashBuf[0])<<8)|uint16(hashBuf[1]))&2047>>3 - 1
+	i2 := BloomByteLength - uint((uint16(hashBuf[2])<<8)|uint16(hashBuf[3]))&2047>>3 - 1
+	i3 := BloomByteLength - uint((uint16(hashBuf[4])<<8)|uint16(hashBuf[5]))&2047>>3 - 1
+
+	return i1, v1, i2, v2, i3, v3
+}
@@ -0,0 +1,130 @@
+// Package hexutil implements JSON encoding of byte strings and quantities
+// as used by Ethereum JSON-RPC, genesis files and state dumps.
+package hexutil
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Bytes marshals as a 0x-prefixed hex string. The prefix is optional when unmarshalling.
+type Bytes []byte
+
+// MarshalText implements encoding.TextMarshaler.
+func (b Bytes) MarshalText() ([]byte, error) {
+	return []byte("0x" + hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Bytes) UnmarshalText(text []byte) error {
+	s := trimPrefix(string(text))
+	if len(s)%2 == 1 {
+		return fmt.Errorf("hex string %q has odd length", text)
+	}
+	dec, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid hex string %q; %w", text, err)
+	}
+	*b = dec
+	return nil
+}
+
+// Big marshals as a 0x-prefixed hex quantity. When unmarshalling, hex and decimal
+// strings as well as JSON numbers are accepted.
+type Big big.Int
+
+// ToInt returns b as *big.Int.
+func (b *Big) ToInt() *big.Int {
+	return (*big.Int)(b)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (b Big) MarshalText() ([]byte, error) {
+	i := big.Int(b)
+	return []byte("0x" + i.Text(16)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *Big) UnmarshalJSON(input []byte) error {
+	return b.UnmarshalText(unquote(input))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *Big) UnmarshalText(text []byte) error {
+	i, err := parseBig(string(text))
+	if err != nil {
+		return err
+	}
+	*b = Big(*i)
+	return nil
+}
+
+// Uint64 marshals as a 0x-prefixed hex quantity. When unmarshalling, hex and decimal
+// strings as well as JSON numbers are accepted.
+type Uint64 uint64
+
+// MarshalText implements encoding.TextMarshaler.
+func (u Uint64) MarshalText() ([]byte, error) {
+	return []byte("0x" + strconv.FormatUint(uint64(u), 16)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *Uint64) UnmarshalJSON(input []byte) error {
+	return u.UnmarshalText(unquote(input))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (u *Uint64) UnmarshalText(text []byte) error {
+	i, err := parseBig(string(text))
+	if err != nil {
+		return err
+	}
+	if !i.IsUint64() {
+		return fmt.Errorf("quantity %q does not fit into uint64", text)
+	}
+	*u = Uint64(i.Uint64())
+	return nil
+}
+
+// parseBig parses a 0x-prefixed hex or a decimal non-negative integer.
+func parseBig(s string) (*big.Int, error) {
+	i := new(big.Int)
+	var ok bool
+	if hasPrefix(s) {
+		hexPart := trimPrefix(s)
+		if hexPart == "" {
+			return nil, fmt.Errorf("empty hex quantity %q", s)
+		}
+		_, ok = i.SetString(hexPart, 16)
+	} else {
+		_, ok = i.SetString(s, 10)
+	}
+	if !ok || i.Sign() < 0 {
+		return nil, fmt.Errorf("invalid quantity %q", s)
+	}
+	return i, nil
+}
+
+// unquote returns the content of a JSON string or the input itself for other JSON values.
+func unquote(input []byte) []byte {
+	var s string
+	if err := json.Unmarshal(input, &s); err == nil {
+		return []byte(s)
+	}
+	return input
+}
+
+func hasPrefix(s string) bool {
+	return strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X")
+}
+
+func trimPrefix(s string) string {
+	if hasPrefix(s) {
+		return s[2:]
+	}
+	return s
+}
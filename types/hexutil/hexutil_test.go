@@ -0,0 +1,72 @@
+package hexutil
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestBig_Unmarshal(t *testing.T) {
+	tests := map[string]int64{
+		`"0x10"`: 16,
+		`"16"`:   16,
+		`16`:     16,
+		`"0x0"`:  0,
+	}
+	for input, want := range tests {
+		var b Big
+		if err := json.Unmarshal([]byte(input), &b); err != nil {
+			t.Fatalf("cannot unmarshal %v; %v", input, err)
+		}
+		if b.ToInt().Int64() != want {
+			t.Fatalf("unexpected value of %v\ngot: %v\nwant: %v", input, b.ToInt(), want)
+		}
+	}
+
+	for _, input := range []string{`"0x"`, `"-1"`, `"abc"`} {
+		var b Big
+		if err := json.Unmarshal([]byte(input), &b); err == nil {
+			t.Fatalf("unmarshal of %v must fail", input)
+		}
+	}
+}
+
+func TestUint64_MarshalUnmarshal(t *testing.T) {
+	enc, err := json.Marshal(Uint64(255))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(enc) != `"0xff"` {
+		t.Fatalf("unexpected encoding\ngot: %s\nwant: \"0xff\"", enc)
+	}
+
+	var u Uint64
+	if err = json.Unmarshal(enc, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u != 255 {
+		t.Fatalf("unexpected value\ngot: %v\nwant: 255", u)
+	}
+
+	if err = json.Unmarshal([]byte(`"0x10000000000000000"`), &u); err == nil {
+		t.Fatal("unmarshal of too large value must fail")
+	}
+}
+
+func TestBytes_MarshalUnmarshal(t *testing.T) {
+	var b Bytes
+	if err := json.Unmarshal([]byte(`"0x0102"`), &b); err != nil {
+		t.Fatal(err)
+	}
+
+	enc, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(enc) != `"0x0102"` {
+		t.Fatalf("unexpected encoding\ngot: %s\nwant: \"0x0102\"", enc)
+	}
+
+	if err = json.Unmarshal([]byte(`"0x012"`), &b); err == nil {
+		t.Fatal("unmarshal of odd length must fail")
+	}
+}
@@ -104,3 +104,39 @@ func TestAddress_UnmarshalText(t *testing.T) {
 		}
 	}
 }
+
+func TestBloom_AddAndTest(t *testing.T) {
+	positive := []string{"testtest", "test", "hallo", "other"}
+	negative := []string{"tes", "lo"}
+
+	var bloom Bloom
+	for _, data := range positive {
+		bloom.Add([]byte(data))
+	}
+
+	for _, data := range positive {
+		if !bloom.Test([]byte(data)) {
+			t.Fatalf("%v must be present in the bloom", data)
+		}
+	}
+	for _, data := range negative {
+		if bloom.Test([]byte(data)) {
+			t.Fatalf("%v must not be present in the bloom", data)
+		}
+	}
+}
+
+func TestBloom_LogsBloom(t *testing.T) {
+	log := &Log{Address: Address{1}, Topics: []Hash{{2}}}
+	bloom := LogsBloom([]*Log{log})
+
+	if !bloom.Test(log.Address.Bytes()) {
+		t.Fatal("address must be present in the bloom")
+	}
+	if !bloom.Test(log.Topics[0].Bytes()) {
+		t.Fatal("topic must be present in the bloom")
+	}
+	if bloom.Test(Hash{3}.Bytes()) {
+		t.Fatal("unexpected topic present in the bloom")
+	}
+}
@@ -0,0 +1,9 @@
+package types
+
+// Withdrawal is a validator withdrawal from the consensus layer (EIP-4895).
+type Withdrawal struct {
+	Index     uint64  `json:"index"`          // monotonically increasing identifier issued by consensus layer
+	Validator uint64  `json:"validatorIndex"` // index of validator associated with withdrawal
+	Address   Address `json:"address"`        // target address for withdrawn ether
+	Amount    uint64  `json:"amount"`         // value of withdrawal in Gwei
+}
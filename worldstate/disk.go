@@ -0,0 +1,139 @@
+package worldstate
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/types"
+	"github.com/Fantom-foundation/Substate/types/rlp"
+)
+
+const (
+	AccountPrefix = "wa" // AccountPrefix + address (160-bit) -> diskAccount
+	StoragePrefix = "wt" // StoragePrefix + address (160-bit) + key (256-bit) -> value
+)
+
+// diskAccount is the DB representation of an account without its storage.
+type diskAccount struct {
+	Nonce   uint64
+	Balance *big.Int
+	Code    []byte
+}
+
+// Spill writes the complete state into disk and releases all memory layers of s.
+// Layers shared with copies of s are kept by the copies. The disk must be dedicated
+// to a single State and must not be modified afterwards.
+func (s *State) Spill(disk db.BaseDB) error {
+	batch := disk.NewBatch()
+	flush := func() error {
+		if batch.ValueSize() < 1<<20 {
+			return nil
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+
+	err := s.top.addresses(func(addr types.Address) error {
+		e, err := s.top.find(addr)
+		if err != nil {
+			return err
+		}
+		storage, err := s.top.storage(addr)
+		if err != nil {
+			return err
+		}
+
+		value, err := rlp.EncodeToBytes(diskAccount{Nonce: e.nonce, Balance: balanceOf(e), Code: e.code})
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(diskAccountKey(addr), value); err != nil {
+			return err
+		}
+		for key, value := range storage {
+			if err = batch.Put(diskSlotKey(addr, key), value.Bytes()); err != nil {
+				return err
+			}
+		}
+		return flush()
+	})
+	if err != nil {
+		return fmt.Errorf("cannot spill state; %w", err)
+	}
+	if err = batch.Write(); err != nil {
+		return fmt.Errorf("cannot spill state; %w", err)
+	}
+
+	s.top = newLayer(newDiskLayer(disk))
+	return nil
+}
+
+func readDiskAccount(disk db.BaseDB, addr types.Address) (*entry, error) {
+	value, err := disk.Get(diskAccountKey(addr))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read account %s; %w", addr, err)
+	}
+
+	var acc diskAccount
+	if err = rlp.DecodeBytes(value, &acc); err != nil {
+		return nil, fmt.Errorf("cannot decode account %s; %w", addr, err)
+	}
+	return &entry{nonce: acc.Nonce, balance: acc.Balance, code: acc.Code}, nil
+}
+
+func readDiskSlot(disk db.BaseDB, addr types.Address, key types.Hash) (types.Hash, bool, error) {
+	value, err := disk.Get(diskSlotKey(addr, key))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return types.Hash{}, false, nil
+	}
+	if err != nil {
+		return types.Hash{}, false, fmt.Errorf("cannot read slot %s of account %s; %w", key, addr, err)
+	}
+	return types.BytesToHash(value), true, nil
+}
+
+// readDiskStorage adds slots of addr which are not yet present into storage.
+func readDiskStorage(disk db.BaseDB, addr types.Address, storage map[types.Hash]types.Hash) error {
+	prefix := append([]byte(StoragePrefix), addr[:]...)
+	iter := disk.NewIterator(prefix, nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		key := types.BytesToHash(iter.Key()[len(prefix):])
+		if _, found := storage[key]; !found {
+			storage[key] = types.BytesToHash(iter.Value())
+		}
+	}
+	return iter.Error()
+}
+
+func readDiskAddresses(disk db.BaseDB, visit func(addr types.Address) error) error {
+	iter := disk.NewIterator([]byte(AccountPrefix), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		if err := visit(types.BytesToAddress(iter.Key()[len(AccountPrefix):])); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func diskAccountKey(addr types.Address) []byte {
+	return append([]byte(AccountPrefix), addr[:]...)
+}
+
+func diskSlotKey(addr types.Address, key types.Hash) []byte {
+	k := append([]byte(StoragePrefix), addr[:]...)
+	return append(k, key[:]...)
+}
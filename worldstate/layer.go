@@ -0,0 +1,191 @@
+package worldstate
+
+import (
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+// layer holds accounts changed relatively to its parent. A layer is either a memory layer
+// with accounts, or a disk layer backed by a database, which is always the bottom layer.
+// Layers shared by several States are frozen and never modified.
+type layer struct {
+	accounts map[types.Address]*entry
+	parent   *layer
+	depth    int // number of memory layers below this layer
+
+	disk db.BaseDB // only set for disk layers
+}
+
+// entry is the state of an account within a layer.
+type entry struct {
+	nonce   uint64
+	balance *big.Int
+	code    []byte
+
+	// storage holds slots written within the layer
+	storage map[types.Hash]types.Hash
+
+	// deleted means the account does not exist, accounts of lower layers are hidden
+	deleted bool
+
+	// cleared means storage of lower layers is hidden
+	cleared bool
+}
+
+func newLayer(parent *layer) *layer {
+	l := &layer{accounts: make(map[types.Address]*entry), parent: parent}
+	if parent != nil && parent.disk == nil {
+		l.depth = parent.depth + 1
+	}
+	return l
+}
+
+func newDiskLayer(disk db.BaseDB) *layer {
+	return &layer{disk: disk}
+}
+
+// find returns the account of addr. Nil is returned if the account does not exist.
+func (l *layer) find(addr types.Address) (*entry, error) {
+	for cur := l; cur != nil; cur = cur.parent {
+		if cur.disk != nil {
+			return readDiskAccount(cur.disk, addr)
+		}
+		if e, found := cur.accounts[addr]; found {
+			if e.deleted {
+				return nil, nil
+			}
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+// slot returns value of storage key of addr and whether the slot exists.
+func (l *layer) slot(addr types.Address, key types.Hash) (types.Hash, bool, error) {
+	for cur := l; cur != nil; cur = cur.parent {
+		if cur.disk != nil {
+			return readDiskSlot(cur.disk, addr, key)
+		}
+		e, found := cur.accounts[addr]
+		if !found {
+			continue
+		}
+		if value, found := e.storage[key]; found {
+			return value, true, nil
+		}
+		if e.deleted || e.cleared {
+			break
+		}
+	}
+	return types.Hash{}, false, nil
+}
+
+// storage returns all slots of addr.
+func (l *layer) storage(addr types.Address) (map[types.Hash]types.Hash, error) {
+	storage := make(map[types.Hash]types.Hash)
+	for cur := l; cur != nil; cur = cur.parent {
+		if cur.disk != nil {
+			return storage, readDiskStorage(cur.disk, addr, storage)
+		}
+		e, found := cur.accounts[addr]
+		if !found {
+			continue
+		}
+		for key, value := range e.storage {
+			if _, found = storage[key]; !found {
+				storage[key] = value
+			}
+		}
+		if e.deleted || e.cleared {
+			break
+		}
+	}
+	return storage, nil
+}
+
+// addresses calls visit for every existing account.
+func (l *layer) addresses(visit func(addr types.Address) error) error {
+	seen := make(map[types.Address]struct{})
+	for cur := l; cur != nil; cur = cur.parent {
+		if cur.disk != nil {
+			return readDiskAddresses(cur.disk, func(addr types.Address) error {
+				if _, found := seen[addr]; found {
+					return nil
+				}
+				return visit(addr)
+			})
+		}
+		for addr, e := range cur.accounts {
+			if _, found := seen[addr]; found {
+				continue
+			}
+			seen[addr] = struct{}{}
+			if !e.deleted {
+				if err := visit(addr); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// collapse returns a single memory layer equivalent to memory layers of l placed above its disk layer.
+func (l *layer) collapse() *layer {
+	var disk *layer
+	var layers []*layer
+	for cur := l; cur != nil; cur = cur.parent {
+		if cur.disk != nil {
+			disk = cur
+			break
+		}
+		layers = append(layers, cur)
+	}
+
+	merged := newLayer(disk)
+	// apply layers from the bottom, so upper layers override lower ones
+	for i := len(layers) - 1; i >= 0; i-- {
+		for addr, e := range layers[i].accounts {
+			prev, found := merged.accounts[addr]
+			if !found || e.deleted || e.cleared {
+				merged.accounts[addr] = e.copy()
+				continue
+			}
+			cp := e.copy()
+			cp.cleared = prev.cleared || prev.deleted
+			for key, value := range prev.storage {
+				if _, found = cp.storage[key]; !found {
+					cp.storage[key] = value
+				}
+			}
+			merged.accounts[addr] = cp
+		}
+	}
+
+	// without a disk layer, nothing is hidden by deleted accounts
+	if disk == nil {
+		for addr, e := range merged.accounts {
+			if e.deleted {
+				delete(merged.accounts, addr)
+			}
+		}
+	}
+	return merged
+}
+
+func (e *entry) copy() *entry {
+	cp := &entry{
+		nonce:   e.nonce,
+		balance: e.balance,
+		code:    e.code,
+		storage: make(map[types.Hash]types.Hash, len(e.storage)),
+		deleted: e.deleted,
+		cleared: e.cleared,
+	}
+	for key, value := range e.storage {
+		cp.storage[key] = value
+	}
+	return cp
+}
@@ -0,0 +1,232 @@
+// Package worldstate implements a copy-on-write world state for large states.
+package worldstate
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+const (
+	sizeOfAddress uint64 = 20
+	sizeOfHash    uint64 = 32
+	sizeOfNonce   uint64 = 8
+)
+
+// maxDepth is the number of memory layers after which frozen layers are collapsed into one.
+const maxDepth = 32
+
+// State is a copy-on-write alternative of substate.WorldState. Copy is O(1), the copy and the
+// original share all accounts until they are modified. Modified accounts only hold changed storage slots.
+// State is not safe for concurrent use, but copies may be used concurrently.
+type State struct {
+	top *layer // only layer owned by the State, all lower layers are frozen
+}
+
+// New returns an empty State.
+func New() *State {
+	return &State{top: newLayer(nil)}
+}
+
+// FromWorldState returns State containing all accounts of ws.
+func FromWorldState(ws substate.WorldState) *State {
+	s := New()
+	s.Merge(ws)
+	return s
+}
+
+// Copy returns a copy of s. Changes of s and of the copy are not visible to each other.
+func (s *State) Copy() *State {
+	frozen := s.top
+	if frozen.depth >= maxDepth {
+		frozen = frozen.collapse()
+	}
+	s.top = newLayer(frozen)
+	return &State{top: newLayer(frozen)}
+}
+
+// Add assigns new account to addr, any previous account is replaced.
+func (s *State) Add(addr types.Address, nonce uint64, balance *big.Int, code []byte) *State {
+	s.top.accounts[addr] = &entry{
+		nonce:   nonce,
+		balance: new(big.Int).Set(balance),
+		code:    append([]byte{}, code...),
+		storage: make(map[types.Hash]types.Hash),
+		cleared: true,
+	}
+	return s
+}
+
+// Delete removes the account of addr including its storage.
+func (s *State) Delete(addr types.Address) {
+	s.top.accounts[addr] = &entry{deleted: true, storage: make(map[types.Hash]types.Hash)}
+}
+
+// Merge y into s. If values differ, values from y are saved.
+func (s *State) Merge(y substate.WorldState) {
+	for addr, acc := range y {
+		e, found := s.top.accounts[addr]
+		if !found || e.deleted {
+			e = &entry{storage: make(map[types.Hash]types.Hash), cleared: found}
+			s.top.accounts[addr] = e
+		}
+
+		e.nonce = acc.Nonce
+		e.balance = new(big.Int).Set(acc.Balance)
+		e.code = append([]byte{}, acc.Code...)
+		for key, value := range acc.Storage {
+			e.storage[key] = value
+		}
+	}
+}
+
+// Has returns true if the account of addr exists.
+func (s *State) Has(addr types.Address) (bool, error) {
+	e, err := s.top.find(addr)
+	return e != nil, err
+}
+
+// GetAccount returns a copy of the account of addr including its storage or nil if it does not exist.
+func (s *State) GetAccount(addr types.Address) (*substate.Account, error) {
+	e, err := s.top.find(addr)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	acc := substate.NewAccount(e.nonce, new(big.Int).Set(balanceOf(e)), append([]byte{}, e.code...))
+	acc.Storage, err = s.top.storage(addr)
+	if err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
+
+// GetStorage returns value of storage key of addr and whether the slot exists.
+func (s *State) GetStorage(addr types.Address, key types.Hash) (types.Hash, bool, error) {
+	return s.top.slot(addr, key)
+}
+
+// ForEach calls visit for every account of s. Accounts are visited in no particular order.
+func (s *State) ForEach(visit func(addr types.Address, acc *substate.Account) error) error {
+	return s.top.addresses(func(addr types.Address) error {
+		acc, err := s.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+		return visit(addr, acc)
+	})
+}
+
+// Len returns number of accounts of s.
+func (s *State) Len() (int, error) {
+	var n int
+	err := s.top.addresses(func(types.Address) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// ToWorldState returns all accounts of s as substate.WorldState.
+func (s *State) ToWorldState() (substate.WorldState, error) {
+	ws := substate.NewWorldState()
+	err := s.ForEach(func(addr types.Address, acc *substate.Account) error {
+		ws[addr] = acc
+		return nil
+	})
+	return ws, err
+}
+
+// EstimateIncrementalSize returns estimated size increase of s after y is merged.
+func (s *State) EstimateIncrementalSize(y substate.WorldState) (uint64, error) {
+	var size uint64
+	for addr, acc := range y {
+		e, err := s.top.find(addr)
+		if err != nil {
+			return 0, err
+		}
+
+		if e == nil {
+			// address + nonce + balance + codehash
+			size += sizeOfAddress + sizeOfNonce + uint64(len(acc.Balance.Bytes())) + sizeOfHash
+			size += uint64(len(acc.Storage)) * sizeOfHash
+			continue
+		}
+
+		// only new storage keys
+		for key := range acc.Storage {
+			_, found, err := s.top.slot(addr, key)
+			if err != nil {
+				return 0, err
+			}
+			if !found {
+				size += sizeOfHash
+			}
+		}
+	}
+	return size, nil
+}
+
+// Diff returns the difference set between s and y (z = s\y) with semantics of substate.WorldState.Diff.
+func (s *State) Diff(y *State) (substate.WorldState, error) {
+	z := substate.NewWorldState()
+	err := s.ForEach(func(addr types.Address, acc *substate.Account) error {
+		yAcc, err := y.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+
+		x := substate.WorldState{addr: acc}
+		if yAcc == nil {
+			z.Merge(x)
+			return nil
+		}
+		z.Merge(x.Diff(substate.WorldState{addr: yAcc}))
+		return nil
+	})
+	return z, err
+}
+
+// Equal returns true if s and y contain equal accounts.
+func (s *State) Equal(y *State) (bool, error) {
+	sLen, err := s.Len()
+	if err != nil {
+		return false, err
+	}
+	yLen, err := y.Len()
+	if err != nil {
+		return false, err
+	}
+	if sLen != yLen {
+		return false, nil
+	}
+
+	equal := true
+	err = s.ForEach(func(addr types.Address, acc *substate.Account) error {
+		yAcc, err := y.GetAccount(addr)
+		if err != nil {
+			return err
+		}
+		if yAcc == nil || !acc.Equal(yAcc) {
+			equal = false
+			return errStop
+		}
+		return nil
+	})
+	if err == errStop {
+		err = nil
+	}
+	return equal, err
+}
+
+func balanceOf(e *entry) *big.Int {
+	if e.balance == nil {
+		return new(big.Int)
+	}
+	return e.balance
+}
+
+// errStop stops iteration of ForEach without an error.
+var errStop = errors.New("stop iteration")
@@ -0,0 +1,155 @@
+package worldstate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Substate/db"
+	"github.com/Fantom-foundation/Substate/substate"
+	"github.com/Fantom-foundation/Substate/types"
+)
+
+func newTestWorldState() substate.WorldState {
+	ws := substate.NewWorldState().
+		Add(types.Address{1}, 1, big.NewInt(100), []byte{1}).
+		Add(types.Address{2}, 2, big.NewInt(200), nil)
+	ws[types.Address{1}].Storage[types.Hash{1}] = types.Hash{11}
+	ws[types.Address{1}].Storage[types.Hash{2}] = types.Hash{12}
+	return ws
+}
+
+func mustWorldState(t *testing.T, s *State) substate.WorldState {
+	ws, err := s.ToWorldState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+func TestState_MergeMatchesWorldState(t *testing.T) {
+	want := newTestWorldState()
+	s := FromWorldState(want)
+
+	update := substate.NewWorldState().
+		Add(types.Address{1}, 3, big.NewInt(50), []byte{2}).
+		Add(types.Address{3}, 0, big.NewInt(1), nil)
+	update[types.Address{1}].Storage[types.Hash{2}] = types.Hash{22}
+	update[types.Address{1}].Storage[types.Hash{3}] = types.Hash{33}
+
+	wantSize := want.EstimateIncrementalSize(update)
+	gotSize, err := s.EstimateIncrementalSize(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotSize != wantSize {
+		t.Fatalf("unexpected incremental size\ngot: %v\nwant: %v", gotSize, wantSize)
+	}
+
+	s = s.Copy()
+	s.Merge(update)
+	want.Merge(update)
+
+	if got := mustWorldState(t, s); !got.Equal(want) {
+		t.Fatalf("unexpected state\ngot: %v\nwant: %v", got, want)
+	}
+}
+
+func TestState_CopyIsIsolated(t *testing.T) {
+	s := FromWorldState(newTestWorldState())
+	cp := s.Copy()
+
+	cp.Merge(substate.NewWorldState().Add(types.Address{1}, 5, big.NewInt(0), nil))
+	s.Delete(types.Address{2})
+
+	acc, err := s.GetAccount(types.Address{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Nonce != 1 {
+		t.Fatalf("original was changed by its copy; nonce %v", acc.Nonce)
+	}
+	if has, err := cp.Has(types.Address{2}); err != nil || !has {
+		t.Fatalf("copy was changed by the original; %v", err)
+	}
+
+	diff, err := cp.Diff(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff) != 2 || diff[types.Address{1}].Nonce != 5 || diff[types.Address{2}] == nil {
+		t.Fatalf("unexpected diff %v", diff)
+	}
+}
+
+func TestState_DeleteClearsStorage(t *testing.T) {
+	s := FromWorldState(newTestWorldState())
+	s.Delete(types.Address{1})
+	s = s.Copy()
+	s.Merge(substate.NewWorldState().Add(types.Address{1}, 0, big.NewInt(0), nil))
+
+	if _, found, err := s.GetStorage(types.Address{1}, types.Hash{1}); err != nil || found {
+		t.Fatalf("storage of deleted account is visible; %v", err)
+	}
+}
+
+func TestState_AddReplacesAccount(t *testing.T) {
+	s := FromWorldState(newTestWorldState()).Copy()
+	s.Add(types.Address{1}, 7, big.NewInt(7), nil)
+
+	acc, err := s.GetAccount(types.Address{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Nonce != 7 || len(acc.Storage) != 0 {
+		t.Fatalf("unexpected account %v", acc)
+	}
+}
+
+func TestState_CollapsesDeepCopies(t *testing.T) {
+	s := New()
+	for i := 0; i < 3*maxDepth; i++ {
+		s.Merge(substate.NewWorldState().Add(types.Address{byte(i)}, uint64(i), big.NewInt(1), nil))
+		s = s.Copy()
+	}
+
+	if s.top.depth > maxDepth+1 {
+		t.Fatalf("layers were not collapsed; depth %v", s.top.depth)
+	}
+	if n, err := s.Len(); err != nil || n != 3*maxDepth {
+		t.Fatalf("unexpected number of accounts %v; %v", n, err)
+	}
+}
+
+func TestState_Spill(t *testing.T) {
+	disk, err := db.NewDefaultBaseDB(t.TempDir() + "state-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer disk.Close()
+
+	s := FromWorldState(newTestWorldState())
+	s.Delete(types.Address{2})
+	before := s.Copy()
+	if err = s.Spill(disk); err != nil {
+		t.Fatal(err)
+	}
+
+	if equal, err := s.Equal(before); err != nil || !equal {
+		t.Fatalf("spilled state differs; %v", err)
+	}
+
+	// changes above the disk layer
+	update := substate.NewWorldState().Add(types.Address{1}, 2, big.NewInt(100), []byte{1})
+	update[types.Address{1}].Storage[types.Hash{3}] = types.Hash{33}
+	s.Merge(update)
+	before.Merge(update)
+
+	if got, want := mustWorldState(t, s), mustWorldState(t, before); !got.Equal(want) {
+		t.Fatalf("unexpected state\ngot: %v\nwant: %v", got, want)
+	}
+
+	s.Delete(types.Address{1})
+	if n, err := s.Len(); err != nil || n != 0 {
+		t.Fatalf("unexpected number of accounts %v; %v", n, err)
+	}
+}